@@ -16,7 +16,9 @@ limitations under the License.
 
 // Package main is the main package for the fetcher. The fetcher knows how to
 // collect a directory tree of release artifacts given a configuration file
-// indicating the desired top-level packages.
+// indicating the desired top-level packages. The directory of each package is
+// only replaced once all of its artifacts are downloaded and verified, and
+// records their sources in a lock file.
 package main
 
 import (
@@ -28,6 +30,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	ghclient "github.com/google/go-github/v33/github"
 	"golang.org/x/oauth2"
@@ -38,53 +41,108 @@ import (
 )
 
 var (
-	version *string
+	version    *string
+	configPath *string
+	output     *string
+	minors     *int
+	versions   *string
+	include    *string
+	exclude    *string
+	workers    *int
+	retries    *int
+	mirror     *string
+	dryRun     *bool
 )
 
 func init() {
 	version = flag.String("release", common.LATEST_VERSION, "the target version")
+	configPath = flag.String("config", filepath.Join("cmd", "fetcher", "kodata", "config.yaml"), "the path of the configuration file")
+	output = flag.String("output", filepath.Join("cmd", "operator", "kodata"), "the directory to write the packages to")
+	minors = flag.Int("minors", 4, "the number of minor versions to keep, up to the target version")
+	versions = flag.String("versions", "", "the comma-separated list of versions to fetch instead of the latest minors, e.g. v0.24,v0.23.1")
+	include = flag.String("include", "", "the comma-separated list of packages to fetch, all of them if empty")
+	exclude = flag.String("exclude", "", "the comma-separated list of packages to skip")
+	workers = flag.Int("workers", 4, "the maximum number of concurrent downloads")
+	retries = flag.Int("retries", 3, "the number of times a failed download is retried")
+	mirror = flag.String("mirror", "", "the HTTP or file:// URL of a mirror to download the assets from, by the host and the path of their URLs")
+	dryRun = flag.Bool("dry-run", false, "print the changes between the output directory and the upstream releases, without writing them")
 }
 
 func main() {
 	flag.Parse()
-	latestVersion := *version
-
-	cfg, err := packages.ReadConfig("cmd/fetcher/kodata/config.yaml")
-	if err != nil {
-		log.Print("Unable to read config: ", err)
-		os.Exit(2)
-	}
 
 	ctx := context.Background()
 	client := getClient(ctx)
+	if client == nil {
+		log.Print("Using anonymous GitHub access, set $GITHUB_TOKEN to raise the rate limit")
+	}
 	ghClient := ghclient.NewClient(client)
-	repos := make(map[string][]packages.Release, len(cfg))
-	for _, v := range cfg {
+
+	downloader := packages.NewDownloader(http.DefaultClient)
+	downloader.Workers = *workers
+	downloader.Retries = *retries
+	downloader.Mirror = *mirror
+
+	if err := run(ctx, ghClient, downloader); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run fetches the packages selected by the flags, using the GitHub client to
+// list the releases of the GitHub sources, and the downloader to fetch their
+// assets.
+func run(ctx context.Context, ghClient *ghclient.Client, downloader *packages.Downloader) error {
+	cfg, err := packages.ReadConfig(*configPath)
+	if err != nil {
+		return fmt.Errorf("Unable to read config: %w", err)
+	}
+	pkgs, err := packages.SelectPackages(cfg, splitList(*include), splitList(*exclude))
+	if err != nil {
+		return fmt.Errorf("Unable to select packages: %w", err)
+	}
+
+	repos := make(map[string][]packages.Release, len(pkgs))
+	for _, v := range pkgs {
 		if err := ensureRepo(ctx, repos, ghClient, v.Primary); err != nil {
-			log.Printf("Unable to fetch %s: %v", v.Primary, err)
-			os.Exit(2)
+			return fmt.Errorf("Unable to fetch %s: %w", v.Primary.String(), err)
 		}
 
 		for _, s := range v.Additional {
 			if err := ensureRepo(ctx, repos, ghClient, s); err != nil {
-				log.Printf("Unable to fetch %s: %v", s, err)
-				os.Exit(2)
+				return fmt.Errorf("Unable to fetch %s: %w", s.String(), err)
 			}
 		}
 
-		base := filepath.Join("cmd", "operator", "kodata", v.Name)
-		if err := os.RemoveAll(base); err != nil && !os.IsNotExist(err) {
-			log.Printf("Unable to remove directory %s: %v", base, err)
-			os.Exit(3)
+		var releases []packages.Release
+		if *versions != "" {
+			releases = packages.SelectReleases(splitList(*versions), repos[v.Primary.String()])
+		} else {
+			releases = packages.LastN(*version, *minors, repos[v.Primary.String()])
 		}
-
-		for _, release := range packages.LastN(latestVersion, 4, repos[v.Primary.String()]) {
-			if err := packages.HandleRelease(ctx, http.DefaultClient, *v, release, repos); err != nil {
-				log.Printf("Unable to fetch %s: %v", release, err)
+		if *dryRun {
+			diff, err := packages.DiffPackage(ctx, downloader, *output, *v, releases, repos)
+			if err != nil {
+				return fmt.Errorf("Unable to compare %s: %w", v, err)
 			}
-			log.Printf("Wrote %s ==> %s", v.String(), release.String())
+			fmt.Print(diff)
+			continue
+		}
+		if err := packages.WritePackage(ctx, downloader, *output, *v, releases, repos); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", v, err)
 		}
 	}
+	return nil
+}
+
+// splitList splits the comma-separated list, ignoring the empty items.
+func splitList(list string) []string {
+	var retval []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			retval = append(retval, item)
+		}
+	}
+	return retval
 }
 
 func getClient(ctx context.Context) *http.Client {
@@ -100,9 +158,6 @@ func ensureRepo(ctx context.Context, known map[string][]packages.Release, client
 		return nil
 	}
 	if src.GitHub != (packages.GitHubSource{}) {
-		if client == nil {
-			return fmt.Errorf("must set $GITHUB_TOKEN to use github sources.")
-		}
 		owner, repo := src.OrgRepo()
 		releases, err := github.GetReleases(ctx, client, owner, repo)
 		if err != nil {
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	ghclient "github.com/google/go-github/v33/github"
+	"knative.dev/operator/pkg/packages"
+)
+
+const (
+	config = `
+knative-serving:
+  primary:
+    github:
+      repo: knative/serving
+    exclude:
+    - "serving.yaml"
+`
+	servingCore = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+  labels:
+    serving.knative.dev/release: "v0.2.1"
+`
+)
+
+func TestRun(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("/repos/knative/serving/releases", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[{"tag_name": "v0.2.1", "assets": [
+  {"name": "serving-core.yaml", "browser_download_url": "%[1]s/download/v0.2.1/serving-core.yaml"},
+  {"name": "serving.yaml", "browser_download_url": "%[1]s/download/v0.2.1/serving.yaml"}]}]`, server.URL)
+	})
+	mux.HandleFunc("/download/v0.2.1/serving-core.yaml", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, servingCore)
+	})
+
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(cfg, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	*configPath = cfg
+	*output = filepath.Join(dir, "kodata")
+
+	ghClient := ghclient.NewClient(server.Client())
+	ghClient.BaseURL, _ = url.Parse(server.URL + "/")
+	if err := run(context.Background(), ghClient, packages.NewDownloader(server.Client())); err != nil {
+		t.Fatal("run() =", err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(*output, "knative-serving", "0.2.1", "1-serving-core.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != servingCore {
+		t.Errorf("Expected %q, got %q", servingCore, string(data))
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(*output, "knative-serving", packages.LockFileName)); err != nil {
+		t.Error("Expected the lock file:", err)
+	}
+}
@@ -16,13 +16,18 @@ limitations under the License.
 package main
 
 import (
+	"knative.dev/operator/pkg/reconciler/common"
 	"knative.dev/operator/pkg/reconciler/knativeeventing"
 	"knative.dev/operator/pkg/reconciler/knativeserving"
+	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
 	"knative.dev/pkg/injection/sharedmain"
+	"knative.dev/pkg/signals"
 )
 
 func main() {
-	sharedmain.Main("knative-operator",
+	// Only the ConfigMaps and Secrets labelled as manifest sources are watched.
+	ctx := filteredFactory.WithSelectors(signals.NewContext(), common.ManifestSourceSelector)
+	sharedmain.MainWithContext(ctx, "knative-operator",
 		knativeserving.NewController,
 		knativeeventing.NewController,
 	)
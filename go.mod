@@ -5,9 +5,11 @@ go 1.16
 require (
 	github.com/go-logr/zapr v0.4.0
 	github.com/google/go-cmp v0.5.6
+	github.com/google/go-containerregistry v0.5.2-0.20210709161016-b448abac9a70
 	github.com/google/go-github/v33 v33.0.0
 	github.com/manifestival/client-go-client v0.5.0
 	github.com/manifestival/manifestival v0.7.0
+	go.opencensus.io v0.23.0
 	go.uber.org/zap v1.18.1
 	gocloud.dev v0.22.0
 	golang.org/x/mod v0.4.2
@@ -21,5 +23,6 @@ require (
 	knative.dev/hack v0.0.0-20210622141627-e28525d8d260
 	knative.dev/pkg v0.0.0-20210715175632-d9b7180af6f2
 	knative.dev/serving v0.24.1-0.20210719171254-2575a92d1484
+	sigs.k8s.io/kustomize/api v0.8.8
 	sigs.k8s.io/yaml v1.2.0
 )
@@ -63,6 +63,8 @@ type KComponentSpec interface {
 	GetManifests() []Manifest
 	// GetAdditionalManifests gets the list of additional manifests, which should be installed
 	GetAdditionalManifests() []Manifest
+	// GetManifestVariables gets the user variables, which are replaced in the manifest URLs
+	GetManifestVariables() map[string]string
 
 	// GetHighAvailability returns means to set the number of desired replicas
 	GetHighAvailability() *HighAvailability
@@ -145,6 +147,11 @@ type CommonSpec struct {
 	// +optional
 	AdditionalManifests []Manifest `json:"additionalManifests,omitempty"`
 
+	// ManifestVariables specifies the user variables, which are replaced in the URLs of
+	// spec.manifests and spec.additionalManifests. The variable NAME is referenced as ${NAME}.
+	// +optional
+	ManifestVariables map[string]string `json:"manifestVariables,omitempty"`
+
 	// HighAvailability allows specification of HA control plane.
 	// +optional
 	HighAvailability *HighAvailability `json:"high-availability,omitempty"`
@@ -180,6 +187,11 @@ func (c *CommonSpec) GetAdditionalManifests() []Manifest {
 	return c.AdditionalManifests
 }
 
+// GetManifestVariables implements KComponentSpec.
+func (c *CommonSpec) GetManifestVariables() map[string]string {
+	return c.ManifestVariables
+}
+
 // GetHighAvailability implements KComponentSpec.
 func (c *CommonSpec) GetHighAvailability() *HighAvailability {
 	return c.HighAvailability
@@ -252,6 +264,55 @@ type ResourceRequirementsOverride struct {
 type Manifest struct {
 	// The link of the manifest URL
 	Url string `json:"URL"`
+
+	// The hex-encoded SHA256 digest of the manifest. If set, the operator verifies the
+	// fetched manifest against it before installing anything.
+	// +optional
+	Sha256 string `json:"sha256,omitempty"`
+
+	// CredentialsSecret references a Secret in the namespace of this resource, which contains
+	// the credentials to fetch the manifest with. The Secret contains either a "token" for
+	// bearer authentication, or a "username" and "password" for basic authentication. The
+	// Secret has to be labelled with operator.knative.dev/manifest-source: "true".
+	// +optional
+	CredentialsSecret *corev1.LocalObjectReference `json:"credentialsSecret,omitempty"`
+
+	// CABundleConfigMap references a ConfigMap in the namespace of this resource, which contains
+	// the PEM-encoded CA certificates to trust when fetching the manifest. The ConfigMap has to
+	// be labelled with operator.knative.dev/manifest-source: "true".
+	// +optional
+	CABundleConfigMap *corev1.LocalObjectReference `json:"caBundleConfigMap,omitempty"`
+
+	// Kustomize marks the URL as a kustomization, which the operator builds to get the
+	// manifest. The URL is either a local directory, or a tar archive, optionally gzipped,
+	// with the kustomization.yaml at its root. The manifests of the target version shipped
+	// with the operator are available to the kustomization as the base ../base.
+	// +optional
+	Kustomize bool `json:"kustomize,omitempty"`
+
+	// ConfigMap references the key of a ConfigMap in the namespace of this resource, which
+	// contains the manifest. It is used instead of the URL. The ConfigMap has to be labelled
+	// with operator.knative.dev/manifest-source: "true". The operator watches the ConfigMap,
+	// and reconciles this resource again when its content changes.
+	// +optional
+	ConfigMap *ManifestSourceReference `json:"configMap,omitempty"`
+
+	// Secret references the key of a Secret in the namespace of this resource, which contains
+	// the manifest. It is used instead of the URL. The Secret has to be labelled with
+	// operator.knative.dev/manifest-source: "true". The operator watches the Secret, and
+	// reconciles this resource again when its content changes.
+	// +optional
+	Secret *ManifestSourceReference `json:"secret,omitempty"`
+}
+
+// ManifestSourceReference references the key of a ConfigMap or a Secret in the namespace of
+// the resource.
+type ManifestSourceReference struct {
+	// The name of the ConfigMap or Secret.
+	Name string `json:"name"`
+
+	// The key of the manifest in the ConfigMap or Secret.
+	Key string `json:"key"`
 }
 
 // HighAvailability specifies options for deploying Knative Serving control
@@ -140,3 +140,42 @@ func (es *KnativeEventingStatus) GetManifests() []string {
 func (es *KnativeEventingStatus) SetManifests(manifests []string) {
 	es.Manifests = manifests
 }
+
+// EnabledSources returns the names of the enabled sources, e.g. kafka.
+func (ke *KnativeEventingSpec) EnabledSources() []string {
+	if ke.Source == nil {
+		return nil
+	}
+	var enabled []string
+	if ke.Source.Awssqs.Enabled {
+		enabled = append(enabled, "awssqs")
+	}
+	if ke.Source.Ceph.Enabled {
+		enabled = append(enabled, "ceph")
+	}
+	if ke.Source.Couchdb.Enabled {
+		enabled = append(enabled, "couchdb")
+	}
+	if ke.Source.Github.Enabled {
+		enabled = append(enabled, "github")
+	}
+	if ke.Source.Gitlab.Enabled {
+		enabled = append(enabled, "gitlab")
+	}
+	if ke.Source.Kafka.Enabled {
+		enabled = append(enabled, "kafka")
+	}
+	if ke.Source.Natss.Enabled {
+		enabled = append(enabled, "natss")
+	}
+	if ke.Source.Prometheus.Enabled {
+		enabled = append(enabled, "prometheus")
+	}
+	if ke.Source.Rabbitmq.Enabled {
+		enabled = append(enabled, "rabbitmq")
+	}
+	if ke.Source.Redis.Enabled {
+		enabled = append(enabled, "redis")
+	}
+	return enabled
+}
@@ -83,6 +83,10 @@ type KnativeEventingStatus struct {
 	// The url links of the manifests, separated by comma
 	// +optional
 	Manifests []string `json:"manifests,omitempty"`
+
+	// The sources enabled for the installed release
+	// +optional
+	Sources []string `json:"sources,omitempty"`
 }
 
 // KnativeEventingList contains a list of KnativeEventing
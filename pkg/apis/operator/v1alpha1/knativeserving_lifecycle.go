@@ -139,3 +139,79 @@ func (is *KnativeServingStatus) GetManifests() []string {
 func (is *KnativeServingStatus) SetManifests(manifests []string) {
 	is.Manifests = manifests
 }
+
+// EnabledIngresses returns the names of the enabled ingresses, i.e. istio, kourier, contour and
+// gatewayAPI. Istio is enabled by default.
+func (ks *KnativeServingSpec) EnabledIngresses() []string {
+	if ks.Ingress == nil {
+		return []string{"istio"}
+	}
+	var enabled []string
+	if ks.Ingress.Istio.Enabled {
+		enabled = append(enabled, "istio")
+	}
+	if ks.Ingress.Kourier.Enabled {
+		enabled = append(enabled, "kourier")
+	}
+	if ks.Ingress.Contour.Enabled {
+		enabled = append(enabled, "contour")
+	}
+	if ks.Ingress.GatewayAPI.Enabled {
+		enabled = append(enabled, "gatewayAPI")
+	}
+	return enabled
+}
+
+// TLSEnabled returns whether the automatic TLS is enabled.
+func (ks *KnativeServingSpec) TLSEnabled() bool {
+	return ks.TLS != nil && ks.TLS.Enabled
+}
+
+// MagicDNSEnabled returns whether the magic DNS is enabled.
+func (ks *KnativeServingSpec) MagicDNSEnabled() bool {
+	return ks.Domain != nil && ks.Domain.MagicDNS
+}
+
+// KourierGatewayNamespace returns the namespace the kourier gateway is moved to, or an empty string
+// if kourier is disabled or its gateway is left in the namespace of the manifest.
+func (ks *KnativeServingSpec) KourierGatewayNamespace() string {
+	if ks.Ingress == nil || !ks.Ingress.Kourier.Enabled {
+		return ""
+	}
+	return ks.Ingress.Kourier.GatewayNamespace
+}
+
+// LocalGatewayNamespace returns the namespace of the knative-local-gateway Service, or an empty
+// string if istio is disabled or the Service is left in the default namespace.
+func (ks *KnativeServingSpec) LocalGatewayNamespace() string {
+	if ks.Ingress == nil || !ks.Ingress.Istio.Enabled {
+		return ""
+	}
+	return ks.Ingress.Istio.LocalGatewayNamespace
+}
+
+// IngressReleases returns the releases pinned for the enabled ingresses, keyed by their names. The
+// ingresses without a version or manifests are omitted, and it is nil if no release is pinned.
+func (ks *KnativeServingSpec) IngressReleases() map[string]IngressRelease {
+	if ks.Ingress == nil {
+		return nil
+	}
+	var releases map[string]IngressRelease
+	for name, config := range map[string]struct {
+		enabled bool
+		release IngressRelease
+	}{
+		"istio":      {ks.Ingress.Istio.Enabled, ks.Ingress.Istio.IngressRelease},
+		"kourier":    {ks.Ingress.Kourier.Enabled, ks.Ingress.Kourier.IngressRelease},
+		"contour":    {ks.Ingress.Contour.Enabled, ks.Ingress.Contour.IngressRelease},
+		"gatewayAPI": {ks.Ingress.GatewayAPI.Enabled, ks.Ingress.GatewayAPI.IngressRelease},
+	} {
+		if config.enabled && (config.release.Version != "" || len(config.release.Manifests) != 0) {
+			if releases == nil {
+				releases = map[string]IngressRelease{}
+			}
+			releases[name] = config.release
+		}
+	}
+	return releases
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"reflect"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -148,3 +149,37 @@ func TestKnativeServingVersionMigrationNotEligible(t *testing.T) {
 	ks.MarkVersionMigrationNotEligible("Version migration not eligible.")
 	apistest.CheckConditionFailed(ks, VersionMigrationEligible, t)
 }
+
+func TestKnativeServingEnabledIngresses(t *testing.T) {
+	spec := &KnativeServingSpec{}
+	if got, want := spec.EnabledIngresses(), []string{"istio"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EnabledIngresses() = %v, want %v", got, want)
+	}
+	spec.Ingress = &IngressConfigs{
+		Kourier:    KourierIngressConfiguration{Enabled: true},
+		GatewayAPI: GatewayAPIIngressConfiguration{Enabled: true},
+	}
+	if got, want := spec.EnabledIngresses(), []string{"kourier", "gatewayAPI"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EnabledIngresses() = %v, want %v", got, want)
+	}
+}
+
+func TestKnativeServingIngressReleases(t *testing.T) {
+	spec := &KnativeServingSpec{}
+	if got := spec.IngressReleases(); len(got) != 0 {
+		t.Errorf("IngressReleases() = %v, want none", got)
+	}
+	spec.Ingress = &IngressConfigs{
+		Istio:   IstioIngressConfiguration{Enabled: true},
+		Kourier: KourierIngressConfiguration{Enabled: true},
+		Contour: ContourIngressConfiguration{IngressRelease: IngressRelease{Version: "0.23"}},
+	}
+	if got := spec.IngressReleases(); got != nil {
+		t.Errorf("IngressReleases() = %v, want nil", got)
+	}
+	spec.Ingress.Istio.Version = "0.24.1"
+	want := map[string]IngressRelease{"istio": {Version: "0.24.1"}}
+	if got := spec.IngressReleases(); !reflect.DeepEqual(got, want) {
+		t.Errorf("IngressReleases() = %v, want %v", got, want)
+	}
+}
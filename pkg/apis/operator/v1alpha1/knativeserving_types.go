@@ -68,6 +68,14 @@ type KnativeServingSpec struct {
 
 	// Ingress allows configuration of different ingress adapters to be shipped.
 	Ingress *IngressConfigs `json:"ingress,omitempty"`
+
+	// TLS configures the automatic TLS with net-certmanager.
+	// +optional
+	TLS *TLSConfiguration `json:"tls,omitempty"`
+
+	// Domain configures the domains of the Knative Services in the config-domain ConfigMap.
+	// +optional
+	Domain *DomainConfiguration `json:"domain,omitempty"`
 }
 
 // KnativeServingStatus defines the observed state of KnativeServing
@@ -81,6 +89,30 @@ type KnativeServingStatus struct {
 	// The url links of the manifests, separated by comma
 	// +optional
 	Manifests []string `json:"manifests,omitempty"`
+
+	// The ingresses enabled for the installed release
+	// +optional
+	Ingresses []string `json:"ingresses,omitempty"`
+
+	// The releases pinned for the ingresses of the installed release, keyed by their names
+	// +optional
+	IngressReleases map[string]IngressRelease `json:"ingressReleases,omitempty"`
+
+	// Whether the automatic TLS was enabled for the installed release
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+
+	// Whether the magic DNS was enabled for the installed release
+	// +optional
+	MagicDNS bool `json:"magicDNS,omitempty"`
+
+	// The namespace the kourier gateway was moved to for the installed release
+	// +optional
+	KourierGatewayNamespace string `json:"kourierGatewayNamespace,omitempty"`
+
+	// The namespace of the knative-local-gateway Service for the installed release
+	// +optional
+	LocalGatewayNamespace string `json:"localGatewayNamespace,omitempty"`
 }
 
 // KnativeServingList contains a list of KnativeServing
@@ -95,6 +127,50 @@ type KnativeServingList struct {
 type IstioGatewayOverride struct {
 	// A map of values to replace the "selector" values in the knative-ingress-gateway and knative-local-gateway(cluster-local-gateway)
 	Selector map[string]string `json:"selector,omitempty"`
+
+	// Servers replace the "servers" of the knative-ingress-gateway and knative-local-gateway(cluster-local-gateway), if set.
+	// +optional
+	Servers []IstioGatewayServer `json:"servers,omitempty"`
+}
+
+// IstioGatewayServer describes a server of an Istio Gateway.
+type IstioGatewayServer struct {
+	// Port is the port, on which the Gateway listens for the server.
+	Port IstioGatewayPort `json:"port"`
+
+	// Hosts are the hosts exposed by the server.
+	Hosts []string `json:"hosts"`
+
+	// TLS configures the TLS termination of the server.
+	// +optional
+	TLS *IstioGatewayTLS `json:"tls,omitempty"`
+}
+
+// IstioGatewayPort describes a port of an Istio Gateway.
+type IstioGatewayPort struct {
+	// Number is the port number.
+	Number int32 `json:"number"`
+
+	// Name is the name of the port.
+	Name string `json:"name"`
+
+	// Protocol is one of HTTP, HTTPS, GRPC, HTTP2, MONGO, TCP and TLS.
+	Protocol string `json:"protocol"`
+}
+
+// IstioGatewayTLS describes the TLS settings of a server of an Istio Gateway.
+type IstioGatewayTLS struct {
+	// Mode is one of PASSTHROUGH, SIMPLE, MUTUAL, AUTO_PASSTHROUGH and ISTIO_MUTUAL. It is
+	// required for the protocol HTTPS and along with a CredentialName.
+	Mode string `json:"mode,omitempty"`
+
+	// CredentialName is the name of the Secret, which holds the TLS certificate and key.
+	// +optional
+	CredentialName string `json:"credentialName,omitempty"`
+
+	// HTTPSRedirect redirects the HTTP requests to HTTPS.
+	// +optional
+	HTTPSRedirect bool `json:"httpsRedirect,omitempty"`
 }
 
 // CustomCerts refers to either a ConfigMap or Secret containing valid
@@ -107,17 +183,54 @@ type CustomCerts struct {
 	Name string `json:"name"`
 }
 
+// DomainConfiguration specifies the domains of the Knative Services.
+type DomainConfiguration struct {
+	// Static is the default domain of the Knative Services, e.g. example.com. It cannot be set
+	// along with MagicDNS.
+	// +optional
+	Static string `json:"static,omitempty"`
+
+	// MagicDNS installs the default-domain Job, which sets the default domain to a magic DNS
+	// domain, e.g. sslip.io, based on the IP address of the ingress gateway.
+	// +optional
+	MagicDNS bool `json:"magicDNS,omitempty"`
+
+	// Selectors maps the domains to the labels of the Knative Services, which use them.
+	// +optional
+	Selectors map[string]map[string]string `json:"selectors,omitempty"`
+}
+
+// TLSConfiguration specifies the options of the automatic TLS.
+type TLSConfiguration struct {
+	// Enabled installs net-certmanager, and enables the automatic TLS in the config-network ConfigMap.
+	Enabled bool `json:"enabled"`
+
+	// ClusterIssuer is the name of the cert-manager ClusterIssuer, which issues the certificates.
+	// +optional
+	ClusterIssuer string `json:"clusterIssuer,omitempty"`
+}
+
 // IngressConfigs specifies options for the ingresses.
 type IngressConfigs struct {
-	Istio   IstioIngressConfiguration   `json:"istio"`
-	Kourier KourierIngressConfiguration `json:"kourier"`
-	Contour ContourIngressConfiguration `json:"contour"`
+	Istio      IstioIngressConfiguration      `json:"istio"`
+	Kourier    KourierIngressConfiguration    `json:"kourier"`
+	Contour    ContourIngressConfiguration    `json:"contour"`
+	GatewayAPI GatewayAPIIngressConfiguration `json:"gatewayAPI"`
+
+	// Default is the ingress, whose class is set as the ingress.class of the config-network
+	// ConfigMap if several ingresses are enabled. It is one of istio, kourier, contour and
+	// gatewayAPI.
+	// +optional
+	Default string `json:"default,omitempty"`
 }
 
 // IstioIngressConfiguration specifies options for the istio ingresses.
 type IstioIngressConfiguration struct {
 	Enabled bool `json:"enabled"`
 
+	// IngressRelease pins the release of the ingress.
+	IngressRelease `json:",inline"`
+
 	// KnativeIngressGateway overrides the knative-ingress-gateway.
 	// +optional
 	KnativeIngressGateway *IstioGatewayOverride `json:"knative-ingress-gateway,omitempty"`
@@ -125,17 +238,136 @@ type IstioIngressConfiguration struct {
 	// KnativeLocalGateway overrides the knative-local-gateway.
 	// +optional
 	KnativeLocalGateway *IstioGatewayOverride `json:"knative-local-gateway,omitempty"`
+
+	// IngressGatewayNamespace is the namespace of the Istio ingress gateway serving the external
+	// traffic. It defaults to istio-system.
+	// +optional
+	IngressGatewayNamespace string `json:"ingressGatewayNamespace,omitempty"`
+
+	// LocalGatewayNamespace is the namespace of the Istio ingress gateway serving the cluster-local
+	// traffic, where the knative-local-gateway Service is installed. It defaults to istio-system.
+	// +optional
+	LocalGatewayNamespace string `json:"localGatewayNamespace,omitempty"`
+
+	// Revision is the revision of Istio, whose ingress gateways serve the traffic. The selectors of
+	// the Gateways and of the knative-local-gateway Service match the pods labelled with the
+	// revision, unless the selector of the Gateway is overridden.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+}
+
+// IngressRelease specifies the release of an ingress, which is installed instead of the one matching
+// the version of Knative Serving.
+type IngressRelease struct {
+	// Version is the version of the ingress in the catalog, e.g. 0.24.1 or 0.24. It has to be
+	// compatible with the version of Knative Serving.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Manifests are the manifests of the ingress, which are installed instead of the ones in the
+	// catalog. The version, if set, is used to replace their variables.
+	// +optional
+	Manifests []Manifest `json:"manifests,omitempty"`
 }
 
 // KourierIngressConfiguration specifies whether to enable the kourier ingresses.
 type KourierIngressConfiguration struct {
 	Enabled bool `json:"enabled"`
 
+	// IngressRelease pins the release of the ingress.
+	IngressRelease `json:",inline"`
+
 	// ServiceType specifies the service type for kourier gateway.
 	ServiceType v1.ServiceType `json:"service-type,omitempty"`
+
+	// GatewayNamespace is the namespace of the kourier gateway. It defaults to the namespace
+	// of the KnativeServing instance, and must exist beforehand.
+	// +optional
+	GatewayNamespace string `json:"gatewayNamespace,omitempty"`
+
+	// Bootstrap overrides the settings of the Envoy bootstrap configuration of the kourier gateway.
+	// +optional
+	Bootstrap *KourierBootstrapConfiguration `json:"bootstrap,omitempty"`
+}
+
+// KourierBootstrapConfiguration specifies the settings of the Envoy bootstrap configuration of
+// the kourier gateway, i.e. the kourier-bootstrap ConfigMap.
+type KourierBootstrapConfiguration struct {
+	// StatsPort is the port of the listener serving the statistics of the gateway.
+	// +optional
+	StatsPort int32 `json:"statsPort,omitempty"`
+
+	// ControlConnectTimeout is the timeout for the connections of the gateway to the kourier
+	// control plane, e.g. 1s.
+	// +optional
+	ControlConnectTimeout string `json:"controlConnectTimeout,omitempty"`
+
+	// StatsConnectTimeout is the timeout for the connections of the stats listener to the
+	// admin interface of the gateway, e.g. 250ms.
+	// +optional
+	StatsConnectTimeout string `json:"statsConnectTimeout,omitempty"`
 }
 
-// ContourIngressConfiguration specifies whether to enable the contour ingresses.
+// ContourIngressConfiguration specifies options for the contour ingresses.
 type ContourIngressConfiguration struct {
 	Enabled bool `json:"enabled"`
+
+	// IngressRelease pins the release of the ingress.
+	IngressRelease `json:",inline"`
+
+	// External configures the Contour installation for the external traffic.
+	// +optional
+	External *ContourVisibilityConfiguration `json:"external,omitempty"`
+
+	// Internal configures the Contour installation for the cluster-local traffic.
+	// +optional
+	Internal *ContourVisibilityConfiguration `json:"internal,omitempty"`
+}
+
+// ContourVisibilityConfiguration specifies the Contour installation exposing a visibility.
+type ContourVisibilityConfiguration struct {
+	// Class is the Contour class, which the ingresses of the visibility are annotated with.
+	// +optional
+	Class string `json:"class,omitempty"`
+
+	// Namespace is the namespace of the Contour installation, whose envoy Service exposes
+	// the visibility.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// ServiceType specifies the service type of the envoy Service. It only applies to an envoy
+	// Service installed by the operator, e.g. with spec.additionalManifests, since the shipped
+	// net-contour release does not contain the Contour installations.
+	// +optional
+	ServiceType v1.ServiceType `json:"service-type,omitempty"`
+}
+
+// GatewayAPIIngressConfiguration specifies options for the Gateway API ingress.
+type GatewayAPIIngressConfiguration struct {
+	Enabled bool `json:"enabled"`
+
+	// IngressRelease pins the release of the ingress.
+	IngressRelease `json:",inline"`
+
+	// ExternalGateway references the Gateway for the external traffic.
+	// +optional
+	ExternalGateway *GatewayReference `json:"externalGateway,omitempty"`
+
+	// LocalGateway references the Gateway for the cluster-local traffic.
+	// +optional
+	LocalGateway *GatewayReference `json:"localGateway,omitempty"`
+}
+
+// GatewayReference references a Gateway of the Gateway API.
+type GatewayReference struct {
+	// Class is the name of the GatewayClass of the Gateway.
+	// +optional
+	Class string `json:"class,omitempty"`
+
+	// Gateway is the Gateway, in the format namespace/name.
+	Gateway string `json:"gateway"`
+
+	// Service is the Service, which exposes the Gateway, in the format namespace/name.
+	// +optional
+	Service string `json:"service,omitempty"`
 }
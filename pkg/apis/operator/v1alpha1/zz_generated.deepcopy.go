@@ -95,12 +95,23 @@ func (in *CommonSpec) DeepCopyInto(out *CommonSpec) {
 	if in.Manifests != nil {
 		in, out := &in.Manifests, &out.Manifests
 		*out = make([]Manifest, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.AdditionalManifests != nil {
 		in, out := &in.AdditionalManifests, &out.AdditionalManifests
 		*out = make([]Manifest, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ManifestVariables != nil {
+		in, out := &in.ManifestVariables, &out.ManifestVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 	if in.HighAvailability != nil {
 		in, out := &in.HighAvailability, &out.HighAvailability
@@ -155,6 +166,17 @@ func (in ConfigMapData) DeepCopy() ConfigMapData {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ContourIngressConfiguration) DeepCopyInto(out *ContourIngressConfiguration) {
 	*out = *in
+	in.IngressRelease.DeepCopyInto(&out.IngressRelease)
+	if in.External != nil {
+		in, out := &in.External, &out.External
+		*out = new(ContourVisibilityConfiguration)
+		**out = **in
+	}
+	if in.Internal != nil {
+		in, out := &in.Internal, &out.Internal
+		*out = new(ContourVisibilityConfiguration)
+		**out = **in
+	}
 	return
 }
 
@@ -168,6 +190,22 @@ func (in *ContourIngressConfiguration) DeepCopy() *ContourIngressConfiguration {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContourVisibilityConfiguration) DeepCopyInto(out *ContourVisibilityConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContourVisibilityConfiguration.
+func (in *ContourVisibilityConfiguration) DeepCopy() *ContourVisibilityConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ContourVisibilityConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CouchdbSourceConfiguration) DeepCopyInto(out *CouchdbSourceConfiguration) {
 	*out = *in
@@ -237,6 +275,82 @@ func (in *DeploymentOverride) DeepCopy() *DeploymentOverride {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DomainConfiguration) DeepCopyInto(out *DomainConfiguration) {
+	*out = *in
+	if in.Selectors != nil {
+		in, out := &in.Selectors, &out.Selectors
+		*out = make(map[string]map[string]string, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DomainConfiguration.
+func (in *DomainConfiguration) DeepCopy() *DomainConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(DomainConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayAPIIngressConfiguration) DeepCopyInto(out *GatewayAPIIngressConfiguration) {
+	*out = *in
+	in.IngressRelease.DeepCopyInto(&out.IngressRelease)
+	if in.ExternalGateway != nil {
+		in, out := &in.ExternalGateway, &out.ExternalGateway
+		*out = new(GatewayReference)
+		**out = **in
+	}
+	if in.LocalGateway != nil {
+		in, out := &in.LocalGateway, &out.LocalGateway
+		*out = new(GatewayReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayAPIIngressConfiguration.
+func (in *GatewayAPIIngressConfiguration) DeepCopy() *GatewayAPIIngressConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayAPIIngressConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayReference) DeepCopyInto(out *GatewayReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayReference.
+func (in *GatewayReference) DeepCopy() *GatewayReference {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubSourceConfiguration) DeepCopyInto(out *GithubSourceConfiguration) {
 	*out = *in
@@ -289,8 +403,9 @@ func (in *HighAvailability) DeepCopy() *HighAvailability {
 func (in *IngressConfigs) DeepCopyInto(out *IngressConfigs) {
 	*out = *in
 	in.Istio.DeepCopyInto(&out.Istio)
-	out.Kourier = in.Kourier
-	out.Contour = in.Contour
+	in.Kourier.DeepCopyInto(&out.Kourier)
+	in.Contour.DeepCopyInto(&out.Contour)
+	in.GatewayAPI.DeepCopyInto(&out.GatewayAPI)
 	return
 }
 
@@ -304,6 +419,29 @@ func (in *IngressConfigs) DeepCopy() *IngressConfigs {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressRelease) DeepCopyInto(out *IngressRelease) {
+	*out = *in
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = make([]Manifest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressRelease.
+func (in *IngressRelease) DeepCopy() *IngressRelease {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressRelease)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IstioGatewayOverride) DeepCopyInto(out *IstioGatewayOverride) {
 	*out = *in
@@ -314,6 +452,13 @@ func (in *IstioGatewayOverride) DeepCopyInto(out *IstioGatewayOverride) {
 			(*out)[key] = val
 		}
 	}
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]IstioGatewayServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -327,9 +472,69 @@ func (in *IstioGatewayOverride) DeepCopy() *IstioGatewayOverride {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IstioGatewayPort) DeepCopyInto(out *IstioGatewayPort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioGatewayPort.
+func (in *IstioGatewayPort) DeepCopy() *IstioGatewayPort {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioGatewayPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IstioGatewayServer) DeepCopyInto(out *IstioGatewayServer) {
+	*out = *in
+	out.Port = in.Port
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(IstioGatewayTLS)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioGatewayServer.
+func (in *IstioGatewayServer) DeepCopy() *IstioGatewayServer {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioGatewayServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IstioGatewayTLS) DeepCopyInto(out *IstioGatewayTLS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IstioGatewayTLS.
+func (in *IstioGatewayTLS) DeepCopy() *IstioGatewayTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(IstioGatewayTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IstioIngressConfiguration) DeepCopyInto(out *IstioIngressConfiguration) {
 	*out = *in
+	in.IngressRelease.DeepCopyInto(&out.IngressRelease)
 	if in.KnativeIngressGateway != nil {
 		in, out := &in.KnativeIngressGateway, &out.KnativeIngressGateway
 		*out = new(IstioGatewayOverride)
@@ -461,6 +666,11 @@ func (in *KnativeEventingStatus) DeepCopyInto(out *KnativeEventingStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -547,6 +757,16 @@ func (in *KnativeServingSpec) DeepCopyInto(out *KnativeServingSpec) {
 		*out = new(IngressConfigs)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSConfiguration)
+		**out = **in
+	}
+	if in.Domain != nil {
+		in, out := &in.Domain, &out.Domain
+		*out = new(DomainConfiguration)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -569,6 +789,18 @@ func (in *KnativeServingStatus) DeepCopyInto(out *KnativeServingStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Ingresses != nil {
+		in, out := &in.Ingresses, &out.Ingresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IngressReleases != nil {
+		in, out := &in.IngressReleases, &out.IngressReleases
+		*out = make(map[string]IngressRelease, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
 	return
 }
 
@@ -582,9 +814,31 @@ func (in *KnativeServingStatus) DeepCopy() *KnativeServingStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KourierBootstrapConfiguration) DeepCopyInto(out *KourierBootstrapConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KourierBootstrapConfiguration.
+func (in *KourierBootstrapConfiguration) DeepCopy() *KourierBootstrapConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(KourierBootstrapConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KourierIngressConfiguration) DeepCopyInto(out *KourierIngressConfiguration) {
 	*out = *in
+	in.IngressRelease.DeepCopyInto(&out.IngressRelease)
+	if in.Bootstrap != nil {
+		in, out := &in.Bootstrap, &out.Bootstrap
+		*out = new(KourierBootstrapConfiguration)
+		**out = **in
+	}
 	return
 }
 
@@ -601,6 +855,26 @@ func (in *KourierIngressConfiguration) DeepCopy() *KourierIngressConfiguration {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Manifest) DeepCopyInto(out *Manifest) {
 	*out = *in
+	if in.CredentialsSecret != nil {
+		in, out := &in.CredentialsSecret, &out.CredentialsSecret
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.CABundleConfigMap != nil {
+		in, out := &in.CABundleConfigMap, &out.CABundleConfigMap
+		*out = new(v1.LocalObjectReference)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ManifestSourceReference)
+		**out = **in
+	}
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(ManifestSourceReference)
+		**out = **in
+	}
 	return
 }
 
@@ -614,6 +888,22 @@ func (in *Manifest) DeepCopy() *Manifest {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestSourceReference) DeepCopyInto(out *ManifestSourceReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestSourceReference.
+func (in *ManifestSourceReference) DeepCopy() *ManifestSourceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestSourceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NatssSourceConfiguration) DeepCopyInto(out *NatssSourceConfiguration) {
 	*out = *in
@@ -748,3 +1038,19 @@ func (in *SourceConfigs) DeepCopy() *SourceConfigs {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSConfiguration) DeepCopyInto(out *TLSConfiguration) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSConfiguration.
+func (in *TLSConfiguration) DeepCopy() *TLSConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
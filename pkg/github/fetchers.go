@@ -18,26 +18,70 @@ package github
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/go-github/v33/github"
 	"knative.dev/operator/pkg/packages"
 )
 
-// GetReleases returns all the releases in the specified org and repo
+var (
+	// MaxRateLimitWait is the longest time GetReleases waits in total for GitHub rate limits to
+	// reset, before failing. The anonymous requests are limited to 60 per hour, which a token raises.
+	MaxRateLimitWait = 5 * time.Minute
+
+	// MaxRateLimitRetries is the number of times GetReleases retries the requests, which exceeded
+	// a rate limit, before failing.
+	MaxRateLimitRetries = 5
+)
+
+// GetReleases returns all the releases in the specified org and repo. The requests, which exceed
+// a rate limit, are retried once it resets, or after the time given by the Retry-After header,
+// until MaxRateLimitRetries or MaxRateLimitWait is exceeded.
 func GetReleases(ctx context.Context, client *github.Client, org string, repo string) ([]packages.Release, error) {
 	opt := &github.ListOptions{PerPage: 100}
 
 	retval := []packages.Release{}
 
+	retries := 0
+	var waited time.Duration
 	for {
 		releases, resp, err := client.Repositories.ListReleases(ctx, org, repo, opt)
+		if wait, limited := rateLimitWait(resp, err); limited {
+			if err == nil {
+				err = fmt.Errorf("Got HTTP %d: %s", resp.StatusCode, resp.Status)
+			}
+			if waited+wait > MaxRateLimitWait {
+				return nil, fmt.Errorf("GitHub rate limit exceeded for %s/%s for %v, set $GITHUB_TOKEN to raise it: %w",
+					org, repo, (waited + wait).Round(time.Second), err)
+			}
+			if retries >= MaxRateLimitRetries {
+				return nil, fmt.Errorf("GitHub rate limit exceeded for %s/%s after %d retries, set $GITHUB_TOKEN to raise it: %w",
+					org, repo, retries, err)
+			}
+			retries++
+			waited += wait
+			log.Printf("GitHub rate limit exceeded for %s/%s, retrying in %v", org, repo, wait.Round(time.Second))
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
 		if err != nil || resp.StatusCode != 200 {
 			if err == nil {
 				err = fmt.Errorf("Got HTTP %d: %s", resp.StatusCode, resp.Status)
 			}
 			return nil, err
 		}
+		if resp.Rate.Limit > 0 && resp.Rate.Remaining < resp.Rate.Limit/10 {
+			log.Printf("%d of %d GitHub requests left until %v", resp.Rate.Remaining, resp.Rate.Limit, resp.Rate.Reset.Time)
+		}
 		for _, release := range releases {
 			retval = append(retval, makeRelease(org, repo, release))
 		}
@@ -49,6 +93,31 @@ func GetReleases(ctx context.Context, client *github.Client, org string, repo st
 	return retval, nil
 }
 
+// rateLimitWait returns how long to wait before retrying the request, if it
+// exceeded a rate limit.
+func rateLimitWait(resp *github.Response, err error) (time.Duration, bool) {
+	var rateLimitErr *github.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		if wait := time.Until(rateLimitErr.Rate.Reset.Time); wait > 0 {
+			return wait, true
+		}
+		return time.Second, true
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		if abuseErr.RetryAfter != nil {
+			return *abuseErr.RetryAfter, true
+		}
+		return time.Minute, true
+	}
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden) {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
 func makeRelease(org string, repo string, gh *github.RepositoryRelease) packages.Release {
 	retval := packages.Release{
 		Org:     org,
@@ -0,0 +1,144 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+const releasesPage = `[{"tag_name": "v0.2.0", "assets": [{"name": "serving-core.yaml", "browser_download_url": "https://example.com/serving-core.yaml"}]}]`
+
+func newClient(t *testing.T, handler http.HandlerFunc) *github.Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client := github.NewClient(server.Client())
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+	return client
+}
+
+func TestGetReleasesRateLimit(t *testing.T) {
+	tests := []struct {
+		name    string
+		limited func(w http.ResponseWriter)
+		wantErr bool
+	}{{
+		name: "primary rate limit",
+		limited: func(w http.ResponseWriter) {
+			w.Header().Set("X-RateLimit-Limit", "60")
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+		},
+	}, {
+		name: "secondary rate limit",
+		limited: func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have triggered an abuse detection mechanism", "documentation_url": "https://docs.github.com/rest/overview/resources-in-the-rest-api#abuse-rate-limits"}`)
+		},
+	}, {
+		name: "too many requests",
+		limited: func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	}, {
+		name: "rate limit longer than the maximum wait",
+		limited: func(w http.ResponseWriter) {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+		wantErr: true,
+	}, {
+		name: "other failure",
+		limited: func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusNotFound)
+		},
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if requests == 1 {
+					test.limited(w)
+					return
+				}
+				fmt.Fprint(w, releasesPage)
+			})
+
+			releases, err := GetReleases(context.Background(), client, "knative", "serving")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("GetReleases() = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if len(releases) != 1 || releases[0].TagName != "v0.2.0" || len(releases[0].Assets) != 1 {
+				t.Errorf("Unexpected releases: %+v", releases)
+			}
+		})
+	}
+}
+
+func TestGetReleasesRateLimitRetries(t *testing.T) {
+	requests := 0
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := GetReleases(context.Background(), client, "knative", "serving"); err == nil {
+		t.Fatal("GetReleases() = nil, want an error")
+	}
+	if want := MaxRateLimitRetries + 1; requests != want {
+		t.Errorf("Got %d requests, want %d", requests, want)
+	}
+}
+
+func TestGetReleasesRateLimitTotalWait(t *testing.T) {
+	defer func(wait time.Duration) { MaxRateLimitWait = wait }(MaxRateLimitWait)
+	MaxRateLimitWait = 3 * time.Second
+
+	requests := 0
+	client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+
+	if _, err := GetReleases(context.Background(), client, "knative", "serving"); err == nil {
+		t.Fatal("GetReleases() = nil, want an error")
+	}
+	// The second wait would exceed the maximum in total.
+	if requests != 2 {
+		t.Errorf("Got %d requests, want 2", requests)
+	}
+}
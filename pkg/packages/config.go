@@ -21,6 +21,7 @@ import (
 	"errors"
 	"io/ioutil"
 	"regexp"
+	"sort"
 	"strings"
 
 	"golang.org/x/mod/semver"
@@ -40,6 +41,12 @@ type Package struct {
 	// item based on the latest minor (but not patch) versions of Primary.
 	Alternatives bool
 
+	// If Patches is true, an "alternatives" collection contains the Additional
+	// items for each patch version of Primary, rather than for the latest patch
+	// of each minor version. The Additional items are matched to the patch
+	// versions by their release time, like for a primary-style Package.
+	Patches bool `json:",omitempty"`
+
 	// Primary is the primary source of release artifacts; collections of
 	// release artifacts will be numbered based on the primary source's release
 	// numbering scheme.
@@ -192,3 +199,40 @@ func (s *Source) Accept(ver string) func(name string) string {
 
 	return best.Accept
 }
+
+// SelectPackages returns the Packages of the configuration, which are listed in
+// include, or all of them if include is empty, except the ones listed in
+// exclude. The Packages are sorted by name.
+func SelectPackages(cfg map[string]*Package, include, exclude []string) ([]*Package, error) {
+	for _, name := range append(append([]string{}, include...), exclude...) {
+		if cfg[name] == nil {
+			return nil, errors.New("Unknown package " + name)
+		}
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, name := range exclude {
+		excluded[name] = true
+	}
+	names := include
+	if len(names) == 0 {
+		names = make([]string, 0, len(cfg))
+		for name := range cfg {
+			names = append(names, name)
+		}
+	}
+
+	retval := make([]*Package, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if excluded[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		retval = append(retval, cfg[name])
+	}
+	sort.Slice(retval, func(i, j int) bool {
+		return retval[i].Name < retval[j].Name
+	})
+	return retval, nil
+}
@@ -105,6 +105,42 @@ b:
 	}
 }
 
+func TestSelectPackages(t *testing.T) {
+	cfg := map[string]*Package{
+		"a": {Name: "a"},
+		"b": {Name: "b"},
+		"c": {Name: "c"},
+	}
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", want: []string{"a", "b", "c"}},
+		{name: "include", include: []string{"c", "a"}, want: []string{"a", "c"}},
+		{name: "exclude", exclude: []string{"b"}, want: []string{"a", "c"}},
+		{name: "include and exclude", include: []string{"a", "b"}, exclude: []string{"b"}, want: []string{"a"}},
+		{name: "unknown package", include: []string{"d"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs, err := SelectPackages(cfg, tt.include, tt.exclude)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SelectPackages() = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, p := range pkgs {
+				got = append(got, p.Name)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SelectPackages() (-want +got): %s", diff)
+			}
+		})
+	}
+}
+
 func TestPackage_String(t *testing.T) {
 	p := Package{
 		Name: "test",
@@ -0,0 +1,275 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PackageDiff describes the changes between the directory of a Package and
+// its upstream releases.
+type PackageDiff struct {
+	Name string
+	// NewVersions are the versions, which are only available upstream.
+	NewVersions []string
+	// RemovedVersions are the versions, which are only available in the
+	// directory.
+	RemovedVersions []string
+	// ChangedVersions are the versions available in both, whose files differ.
+	ChangedVersions []VersionDiff
+}
+
+// VersionDiff describes the changes of the files of a version.
+type VersionDiff struct {
+	Version string
+	// NewFiles and RemovedFiles are the paths of the files, relative to the
+	// directory of the version.
+	NewFiles     []string
+	RemovedFiles []string
+	ChangedFiles []FileDiff
+}
+
+// FileDiff describes the changes of the resources of a file. The resources are
+// identified by their GroupVersionKind, namespace and name.
+type FileDiff struct {
+	Path             string
+	NewResources     []string
+	RemovedResources []string
+	ChangedResources []string
+}
+
+// DiffPackage fetches the assets of the releases of the Package into memory,
+// and compares them with the directory of the Package in root, which is left
+// untouched.
+func DiffPackage(ctx context.Context, d *Downloader, root string, p Package, releases []Release, allReleases map[string][]Release) (*PackageDiff, error) {
+	var files []file
+	for _, release := range releases {
+		files = append(files, releaseFiles("", p, release, allReleases)...)
+	}
+	var mu sync.Mutex
+	upstream := make(map[string][]byte, len(files))
+	if _, err := d.fetchAll(ctx, files, func(f file, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		upstream[f.lockPath] = data
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("Unable to fetch %s: %w", p.String(), err)
+	}
+
+	current, err := readPackageDir(filepath.Join(root, p.Name))
+	if err != nil {
+		return nil, err
+	}
+	return diffFiles(p.Name, current, upstream)
+}
+
+// readPackageDir reads the files in the directory of a Package, by their paths
+// relative to it. The lock file is skipped.
+func readPackageDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == LockFileName {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+// diffFiles compares the files of a Package, by their paths relative to its
+// directory.
+func diffFiles(name string, current, upstream map[string][]byte) (*PackageDiff, error) {
+	currentVersions, upstreamVersions := groupByVersion(current), groupByVersion(upstream)
+	diff := &PackageDiff{
+		Name:            name,
+		NewVersions:     missingKeys(upstreamVersions, currentVersions),
+		RemovedVersions: missingKeys(currentVersions, upstreamVersions),
+	}
+
+	for _, version := range sortedKeys(upstreamVersions) {
+		before, ok := currentVersions[version]
+		if !ok {
+			continue
+		}
+		after := upstreamVersions[version]
+		vd := VersionDiff{
+			Version:      version,
+			NewFiles:     missingKeys(after, before),
+			RemovedFiles: missingKeys(before, after),
+		}
+		for _, path := range sortedKeys(after) {
+			if data, ok := before[path]; ok && !bytes.Equal(data, after[path]) {
+				fd, err := diffResources(path, data, after[path])
+				if err != nil {
+					return nil, fmt.Errorf("Unable to compare %s/%s: %w", version, path, err)
+				}
+				vd.ChangedFiles = append(vd.ChangedFiles, *fd)
+			}
+		}
+		if len(vd.NewFiles) != 0 || len(vd.RemovedFiles) != 0 || len(vd.ChangedFiles) != 0 {
+			diff.ChangedVersions = append(diff.ChangedVersions, vd)
+		}
+	}
+	return diff, nil
+}
+
+// diffResources compares the resources of two revisions of a file.
+func diffResources(path string, before, after []byte) (*FileDiff, error) {
+	beforeResources, err := resourcesByKey(before)
+	if err != nil {
+		return nil, err
+	}
+	afterResources, err := resourcesByKey(after)
+	if err != nil {
+		return nil, err
+	}
+	fd := &FileDiff{
+		Path:             path,
+		NewResources:     missingKeys(afterResources, beforeResources),
+		RemovedResources: missingKeys(beforeResources, afterResources),
+	}
+	for _, key := range sortedKeys(afterResources) {
+		if obj, ok := beforeResources[key]; ok && !reflect.DeepEqual(obj, afterResources[key]) {
+			fd.ChangedResources = append(fd.ChangedResources, key)
+		}
+	}
+	return fd, nil
+}
+
+// resourcesByKey parses the resources of a file by their GroupVersionKind,
+// namespace and name.
+func resourcesByKey(data []byte) (map[string]map[string]interface{}, error) {
+	resources, err := parseResources(data)
+	if err != nil {
+		return nil, err
+	}
+	retval := make(map[string]map[string]interface{}, len(resources))
+	for _, u := range resources {
+		name := u.GetName()
+		if ns := u.GetNamespace(); ns != "" {
+			name = ns + "/" + name
+		}
+		retval[u.GroupVersionKind().String()+" "+name] = u.Object
+	}
+	return retval, nil
+}
+
+// groupByVersion groups the files by their versions, i.e. the first element of
+// their paths, and by their paths relative to the directory of the version.
+func groupByVersion(files map[string][]byte) map[string]map[string][]byte {
+	retval := map[string]map[string][]byte{}
+	for path, data := range files {
+		split := strings.SplitN(path, "/", 2)
+		if len(split) != 2 {
+			continue
+		}
+		if retval[split[0]] == nil {
+			retval[split[0]] = map[string][]byte{}
+		}
+		retval[split[0]][split[1]] = data
+	}
+	return retval
+}
+
+// missingKeys returns the sorted keys of a, which are missing in b.
+func missingKeys(a, b interface{}) []string {
+	var retval []string
+	bv := reflect.ValueOf(b)
+	for _, key := range sortedKeys(a) {
+		if !bv.MapIndex(reflect.ValueOf(key)).IsValid() {
+			retval = append(retval, key)
+		}
+	}
+	return retval
+}
+
+// sortedKeys returns the sorted keys of a map with string keys.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	retval := make([]string, 0, len(keys))
+	for _, key := range keys {
+		retval = append(retval, key.String())
+	}
+	sort.Strings(retval)
+	return retval
+}
+
+// String implements fmt.Stringer.
+func (d *PackageDiff) String() string {
+	var b strings.Builder
+	if len(d.NewVersions) == 0 && len(d.RemovedVersions) == 0 && len(d.ChangedVersions) == 0 {
+		fmt.Fprintf(&b, "%s: no changes\n", d.Name)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "%s:\n", d.Name)
+	for _, v := range d.NewVersions {
+		fmt.Fprintf(&b, "  new version %s\n", v)
+	}
+	for _, v := range d.RemovedVersions {
+		fmt.Fprintf(&b, "  removed version %s\n", v)
+	}
+	for _, vd := range d.ChangedVersions {
+		fmt.Fprintf(&b, "  %s:\n", vd.Version)
+		for _, f := range vd.NewFiles {
+			fmt.Fprintf(&b, "    new file %s\n", f)
+		}
+		for _, f := range vd.RemovedFiles {
+			fmt.Fprintf(&b, "    removed file %s\n", f)
+		}
+		for _, fd := range vd.ChangedFiles {
+			fmt.Fprintf(&b, "    changed file %s\n", fd.Path)
+			for _, r := range fd.NewResources {
+				fmt.Fprintf(&b, "      new %s\n", r)
+			}
+			for _, r := range fd.RemovedResources {
+				fmt.Fprintf(&b, "      removed %s\n", r)
+			}
+			for _, r := range fd.ChangedResources {
+				fmt.Fprintf(&b, "      changed %s\n", r)
+			}
+		}
+	}
+	return b.String()
+}
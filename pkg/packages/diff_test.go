@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const changedCoreAsset = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+  labels:
+    serving.knative.dev/release: "v0.2.0"
+    example.com/team: serving
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-network
+  namespace: knative-serving
+`
+
+func TestDiffPackage(t *testing.T) {
+	server := assetServer(t)
+	p := Package{
+		Name:    "test",
+		Primary: Source{GitHub: GitHubSource{Repo: "knative/test"}},
+	}
+	releases := []Release{{
+		Org:     "knative",
+		Repo:    "test",
+		TagName: "v0.2.0",
+		Assets: []Asset{
+			{Name: "core.yaml", URL: server.URL + "/v0.2.0/core.yaml"},
+			{Name: "serving-crds.yaml", URL: server.URL + "/v0.2.0/serving-crds.yaml"},
+		},
+	}}
+
+	root := t.TempDir()
+	current := map[string]string{
+		"0.1.0/1-core.yaml":  coreAsset,
+		"0.2.0/2-core.yaml":  changedCoreAsset,
+		"0.2.0/3-extra.yaml": crdsAsset,
+		LockFileName:         "assets: []\n",
+	}
+	for path, data := range current {
+		path = filepath.Join(root, p.Name, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diff, err := DiffPackage(context.Background(), NewDownloader(http.DefaultClient), root, p, releases, nil)
+	if err != nil {
+		t.Fatal("DiffPackage() =", err)
+	}
+	want := &PackageDiff{
+		Name:            "test",
+		RemovedVersions: []string{"0.1.0"},
+		ChangedVersions: []VersionDiff{{
+			Version:      "0.2.0",
+			NewFiles:     []string{"1-serving-crds.yaml"},
+			RemovedFiles: []string{"3-extra.yaml"},
+			ChangedFiles: []FileDiff{{
+				Path:             "2-core.yaml",
+				RemovedResources: []string{"/v1, Kind=ConfigMap knative-serving/config-network"},
+				ChangedResources: []string{"/v1, Kind=Namespace knative-serving"},
+			}},
+		}},
+	}
+	if diff := cmp.Diff(want, diff); diff != "" {
+		t.Errorf("Wrong diff (-want +got): %s", diff)
+	}
+
+	wantReport := `test:
+  removed version 0.1.0
+  0.2.0:
+    new file 1-serving-crds.yaml
+    removed file 3-extra.yaml
+    changed file 2-core.yaml
+      removed /v1, Kind=ConfigMap knative-serving/config-network
+      changed /v1, Kind=Namespace knative-serving
+`
+	if got := diff.String(); got != wantReport {
+		t.Errorf("Wrong report (-want +got): %s", cmp.Diff(wantReport, got))
+	}
+
+	// The directory of the package is left untouched.
+	if data, err := ioutil.ReadFile(filepath.Join(root, p.Name, "0.2.0", "2-core.yaml")); err != nil || string(data) != changedCoreAsset {
+		t.Errorf("Expected the directory to be untouched, got %q, %v", string(data), err)
+	}
+}
+
+func TestDiffPackageNoChanges(t *testing.T) {
+	diff, err := diffFiles("test", map[string][]byte{"0.2.0/1-core.yaml": []byte(coreAsset)},
+		map[string][]byte{"0.2.0/1-core.yaml": []byte(coreAsset)})
+	if err != nil {
+		t.Fatal("diffFiles() =", err)
+	}
+	if got, want := diff.String(), "test: no changes\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
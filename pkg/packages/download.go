@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Downloader downloads the assets of the releases.
+type Downloader struct {
+	// Client is the HTTP client to download the assets with. The default
+	// client is used if nil.
+	Client *http.Client
+	// Workers is the maximum number of concurrent downloads.
+	Workers int
+	// Retries is the number of times a failed download is retried.
+	Retries int
+	// Backoff is the delay before the first retry, which doubles for each
+	// further retry.
+	Backoff time.Duration
+	// Mirror is the HTTP or file:// URL of a mirror, which the assets are
+	// downloaded from instead of their own URLs, if set. The assets are looked
+	// up by the host and the path of their URLs, e.g. the asset
+	// https://example.com/v0.1.0/a.yaml is downloaded from
+	// file:///mirror/example.com/v0.1.0/a.yaml with the mirror file:///mirror.
+	Mirror string
+}
+
+// NewDownloader returns a Downloader with the default settings.
+func NewDownloader(client *http.Client) *Downloader {
+	return &Downloader{
+		Client:  client,
+		Workers: 4,
+		Retries: 3,
+		Backoff: time.Second,
+	}
+}
+
+// writeFiles downloads the assets of the files and stores them. It returns the
+// assets it wrote, in the order of the files.
+func (d *Downloader) writeFiles(ctx context.Context, files []file) ([]LockedAsset, error) {
+	return d.fetchAll(ctx, files, func(f file, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(f.path, data, 0644); err != nil {
+			return fmt.Errorf("Unable to write to %s: %w", f.path, err)
+		}
+		return nil
+	})
+}
+
+// fetchAll downloads the assets of the files with a bounded number of workers,
+// and passes them to store. It stops at the first failure, and returns the
+// assets in the order of the files otherwise.
+func (d *Downloader) fetchAll(ctx context.Context, files []file, store func(file, []byte) error) ([]LockedAsset, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := d.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, workers)
+		locked   = make([]LockedAsset, len(files))
+	)
+	for i := range files {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f := files[i]
+			data, err := d.fetch(ctx, f.asset)
+			if err == nil {
+				err = store(f, data)
+			}
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+				return
+			}
+			sum := sha256.Sum256(data)
+			locked[i] = LockedAsset{Path: f.lockPath, URL: f.asset.URL, SHA256: hex.EncodeToString(sum[:])}
+		}(i)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return locked, nil
+}
+
+// fetch downloads the asset, retrying the transient failures, and verifies it.
+func (d *Downloader) fetch(ctx context.Context, asset Asset) ([]byte, error) {
+	source, err := d.assetURL(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := d.Backoff
+	for attempt := 0; ; attempt++ {
+		data, retry, err := d.get(ctx, source)
+		if err == nil {
+			if err := verifyAsset(data, asset.tag); err != nil {
+				return nil, fmt.Errorf("Invalid asset %s: %w", source, err)
+			}
+			return data, nil
+		}
+		if !retry || attempt >= d.Retries {
+			return nil, err
+		}
+		log.Printf("Retrying in %v: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// get downloads the content of the URL. It returns whether the failure, if
+// any, is transient.
+func (d *Downloader) get(ctx context.Context, source string) ([]byte, bool, error) {
+	log.Print(source)
+	if strings.HasPrefix(source, "file://") {
+		u, err := url.Parse(source)
+		if err != nil {
+			return nil, false, err
+		}
+		data, err := ioutil.ReadFile(filepath.FromSlash(u.Path))
+		if err != nil {
+			return nil, false, fmt.Errorf("Unable to read %s: %w", source, err)
+		}
+		return data, false, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("Unable to fetch %s: %w", source, err)
+	}
+	client := d.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("Unable to fetch %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("Unable to fetch %s: %s", source, resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, ctx.Err() == nil, fmt.Errorf("Unable to fetch %s: %w", source, err)
+	}
+	return data, false, nil
+}
+
+// assetURL returns the URL to download the asset from.
+func (d *Downloader) assetURL(asset Asset) (string, error) {
+	if d.Mirror == "" {
+		return asset.URL, nil
+	}
+	u, err := url.Parse(asset.URL)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse %s: %w", asset.URL, err)
+	}
+	return strings.TrimSuffix(d.Mirror, "/") + "/" + u.Host + u.Path, nil
+}
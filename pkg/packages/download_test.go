@@ -0,0 +1,171 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDownloaderRetries(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		count := requests[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/flaky.yaml":
+			if count < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(coreAsset))
+		case "/unavailable.yaml":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		requests int
+		wantErr  bool
+	}{{
+		name:     "transient failures",
+		path:     "/flaky.yaml",
+		requests: 3,
+	}, {
+		name:     "too many transient failures",
+		path:     "/unavailable.yaml",
+		requests: 4,
+		wantErr:  true,
+	}, {
+		name:     "permanent failure",
+		path:     "/missing.yaml",
+		requests: 1,
+		wantErr:  true,
+	}}
+
+	d := NewDownloader(server.Client())
+	d.Backoff = time.Millisecond
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := d.fetch(context.Background(), Asset{URL: server.URL + test.path, tag: "v0.2.0"})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("fetch() = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr && string(data) != coreAsset {
+				t.Errorf("Expected %q, got %q", coreAsset, string(data))
+			}
+			if requests[test.path] != test.requests {
+				t.Errorf("Expected %d requests, got %d", test.requests, requests[test.path])
+			}
+		})
+	}
+}
+
+func TestDownloaderWorkers(t *testing.T) {
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.Write([]byte(coreAsset))
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	var files []file
+	for _, name := range []string{"a.yaml", "b.yaml", "c.yaml", "d.yaml", "e.yaml"} {
+		files = append(files, file{
+			asset:    Asset{URL: server.URL + "/" + name},
+			path:     filepath.Join(root, "v0.2.0", name),
+			lockPath: "v0.2.0/" + name,
+		})
+	}
+
+	d := NewDownloader(server.Client())
+	d.Workers = 2
+	locked, err := d.writeFiles(context.Background(), files)
+	if err != nil {
+		t.Fatal("writeFiles() =", err)
+	}
+	if maxActive > d.Workers {
+		t.Errorf("Expected at most %d concurrent downloads, got %d", d.Workers, maxActive)
+	}
+	for i, f := range files {
+		if locked[i].Path != f.lockPath || locked[i].SHA256 != coreSHA256 {
+			t.Errorf("Wrong locked asset at %d: %+v", i, locked[i])
+		}
+		if data, err := ioutil.ReadFile(f.path); err != nil || string(data) != coreAsset {
+			t.Errorf("Wrong content of %s: %q, %v", f.path, string(data), err)
+		}
+	}
+}
+
+func TestDownloaderMirror(t *testing.T) {
+	dir := t.TempDir()
+	mirrored := filepath.Join(dir, "example.com", "serving", "v0.2.0", "core.yaml")
+	if err := os.MkdirAll(filepath.Dir(mirrored), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(mirrored, []byte(coreAsset), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	asset := Asset{URL: "https://example.com/serving/v0.2.0/core.yaml", tag: "v0.2.0"}
+	for _, mirror := range []string{"file://" + filepath.ToSlash(dir), server.URL + "/"} {
+		t.Run(mirror, func(t *testing.T) {
+			d := NewDownloader(server.Client())
+			d.Mirror = mirror
+			locked, err := d.fetchAll(context.Background(), []file{{asset: asset, lockPath: "0.2.0/core.yaml"}},
+				func(file, []byte) error { return nil })
+			if err != nil {
+				t.Fatal("fetchAll() =", err)
+			}
+			// The lock file records the URL of the asset rather than the mirror.
+			want := []LockedAsset{{Path: "0.2.0/core.yaml", URL: asset.URL, SHA256: coreSHA256}}
+			if diff := cmp.Diff(want, locked); diff != "" {
+				t.Errorf("Wrong locked assets (-want +got): %s", diff)
+			}
+		})
+	}
+}
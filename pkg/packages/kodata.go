@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"golang.org/x/mod/semver"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// LockFileName is the name of the file in the directory of a Package, which
+// records where each of its assets comes from.
+const LockFileName = "lock.yaml"
+
+// releaseLabels are the labels, which record the release of the Knative
+// resources.
+var releaseLabels = []string{"serving.knative.dev/release", "eventing.knative.dev/release"}
+
+// Lock records the source URL and the SHA256 digest of each asset of a
+// Package, so that the changes of a refresh can be reviewed.
+type Lock struct {
+	Assets []LockedAsset `json:"assets"`
+}
+
+// LockedAsset records the source of an asset stored on the disk.
+type LockedAsset struct {
+	// Path is the path of the asset, relative to the directory of the Package.
+	Path string `json:"path"`
+	// URL is the URL, from which the asset was downloaded.
+	URL string `json:"url"`
+	// SHA256 is the hex-encoded SHA256 digest of the asset.
+	SHA256 string `json:"sha256"`
+}
+
+// WritePackage writes the assets of the releases of the Package, along with
+// its lock file, to the directory of the Package in root. The assets are
+// downloaded and verified in a temporary directory first, which then replaces
+// the directory of the Package. The directory is left untouched if any asset
+// fails.
+func WritePackage(ctx context.Context, d *Downloader, root string, p Package, releases []Release, allReleases map[string][]Release) error {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return err
+	}
+	// The temporary directory is created in root to keep it on the same file
+	// system, so that it can be renamed.
+	staging, err := ioutil.TempDir(root, "."+p.Name+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	var files []file
+	for _, release := range releases {
+		files = append(files, releaseFiles(staging, p, release, allReleases)...)
+	}
+	assets, err := d.writeFiles(ctx, files)
+	if err != nil {
+		return fmt.Errorf("Unable to fetch %s: %w", p.String(), err)
+	}
+	for _, release := range releases {
+		log.Printf("Wrote %s ==> %s", p.String(), release.String())
+	}
+
+	lock := Lock{Assets: assets}
+
+	sort.Slice(lock.Assets, func(i, j int) bool {
+		return lock.Assets[i].Path < lock.Assets[j].Path
+	})
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(staging, p.Name)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, LockFileName), data, 0644); err != nil {
+		return err
+	}
+	return replaceDir(path, filepath.Join(root, p.Name), filepath.Join(staging, ".previous"))
+}
+
+// replaceDir replaces the directory dst with the directory src, by moving dst
+// to backup first. The directory dst is restored if src cannot be moved. All
+// the directories have to be on the same file system.
+func replaceDir(src, dst, backup string) error {
+	if err := os.Rename(dst, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("Unable to move %s: %w", dst, err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if rerr := os.Rename(backup, dst); rerr != nil && !os.IsNotExist(rerr) {
+			log.Printf("Unable to restore %s: %v", dst, rerr)
+		}
+		return fmt.Errorf("Unable to move %s: %w", src, err)
+	}
+	return nil
+}
+
+// verifyAsset parses the YAML documents of the asset, and checks that the
+// release labels of its resources match the tag. The labels, which are not
+// semantic versions, e.g. "devel", are ignored, as some upstream releases
+// contain them.
+func verifyAsset(data []byte, tag string) error {
+	resources, err := parseResources(data)
+	if err != nil || !semver.IsValid(tag) {
+		return err
+	}
+	for _, u := range resources {
+		for _, key := range releaseLabels {
+			if version := u.GetLabels()[key]; semver.IsValid(version) && semver.Compare(version, tag) != 0 {
+				return fmt.Errorf("the label %s of %s %s is %s instead of %s", key, u.GetKind(), u.GetName(), version, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// parseResources parses the YAML documents of the asset, skipping the empty
+// ones.
+func parseResources(data []byte) ([]unstructured.Unstructured, error) {
+	var resources []unstructured.Unstructured
+	decoder := k8syaml.NewYAMLToJSONDecoder(bytes.NewReader(data))
+	for {
+		obj := map[string]interface{}{}
+		if err := decoder.Decode(&obj); err == io.EOF {
+			return resources, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if len(obj) != 0 {
+			resources = append(resources, unstructured.Unstructured{Object: obj})
+		}
+	}
+}
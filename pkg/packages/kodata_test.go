@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package packages
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	coreAsset = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: knative-serving
+  labels:
+    serving.knative.dev/release: "v0.2.0"
+`
+	crdsAsset = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: controller
+  labels:
+    serving.knative.dev/release: devel
+`
+	// coreSHA256 is the SHA256 digest of coreAsset.
+	coreSHA256 = "b05435104154dbaeae19e98ebf42cbd5872fb8d6ecd88da1ffacb8a989f58131"
+)
+
+func assetServer(t *testing.T) *httptest.Server {
+	assets := map[string]string{
+		"/v0.2.0/core.yaml":         coreAsset,
+		"/v0.2.0/serving-crds.yaml": crdsAsset,
+		"/v0.2.1/core.yaml":         coreAsset,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		asset, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(asset))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWritePackage(t *testing.T) {
+	server := assetServer(t)
+	p := Package{
+		Name:    "test",
+		Primary: Source{GitHub: GitHubSource{Repo: "knative/test"}},
+	}
+	release := func(tag string, names ...string) Release {
+		r := Release{Org: "knative", Repo: "test", TagName: tag}
+		for _, name := range names {
+			r.Assets = append(r.Assets, Asset{Name: name, URL: server.URL + "/" + tag + "/" + name})
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		releases []Release
+		files    []string
+		wantErr  bool
+	}{{
+		name:     "valid release",
+		releases: []Release{release("v0.2.0", "core.yaml", "serving-crds.yaml")},
+		files:    []string{"0.2.0/1-serving-crds.yaml", "0.2.0/2-core.yaml", LockFileName},
+	}, {
+		name:     "mismatched release label",
+		releases: []Release{release("v0.2.0", "core.yaml"), release("v0.2.1", "core.yaml")},
+		wantErr:  true,
+	}, {
+		name:     "missing asset",
+		releases: []Release{release("v0.2.0", "core.yaml", "missing.yaml")},
+		wantErr:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			stale := filepath.Join(root, p.Name, "0.1.0", "1-stale.yaml")
+			if err := os.MkdirAll(filepath.Dir(stale), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(stale, []byte(coreAsset), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := WritePackage(context.Background(), NewDownloader(http.DefaultClient), root, p, test.releases, nil)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("WritePackage() = %v, wantErr %v", err, test.wantErr)
+			}
+
+			entries, err := ioutil.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("Expected only the directory of the package in %s, got %d entries", root, len(entries))
+			}
+			if test.wantErr {
+				// The previous directory of the package is left untouched.
+				if _, err := os.Stat(stale); err != nil {
+					t.Errorf("Expected %s to be kept: %v", stale, err)
+				}
+				return
+			}
+
+			var files []string
+			filepath.Walk(filepath.Join(root, p.Name), func(path string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					rel, _ := filepath.Rel(filepath.Join(root, p.Name), path)
+					files = append(files, filepath.ToSlash(rel))
+				}
+				return err
+			})
+			if diff := cmp.Diff(test.files, files); diff != "" {
+				t.Errorf("Wrong files (-want +got): %s", diff)
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(root, p.Name, LockFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			lock := Lock{}
+			if err := yaml.Unmarshal(data, &lock); err != nil {
+				t.Fatal(err)
+			}
+			if got := lock.Assets[1]; got.Path != "0.2.0/2-core.yaml" || got.URL != server.URL+"/v0.2.0/core.yaml" || got.SHA256 != coreSHA256 {
+				t.Errorf("Wrong locked asset: %+v", got)
+			}
+		})
+	}
+}
+
+func TestVerifyAsset(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		tag     string
+		wantErr bool
+	}{{
+		name: "matching label",
+		data: coreAsset,
+		tag:  "v0.2.0",
+	}, {
+		name:    "mismatched label",
+		data:    coreAsset,
+		tag:     "v0.2.1",
+		wantErr: true,
+	}, {
+		name: "development label",
+		data: crdsAsset,
+		tag:  "v0.2.1",
+	}, {
+		name: "comment-only document",
+		data: "# Copyright 2021 The Knative Authors\n---\n" + coreAsset,
+		tag:  "v0.2.0",
+	}, {
+		name:    "invalid YAML",
+		data:    "kind: [",
+		tag:     "v0.2.0",
+		wantErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := verifyAsset([]byte(test.data), test.tag); (err != nil) != test.wantErr {
+				t.Errorf("verifyAsset() = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
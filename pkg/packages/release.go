@@ -18,12 +18,8 @@ limitations under the License.
 package packages
 
 import (
-	"context"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -38,6 +34,8 @@ type Asset struct {
 	Name      string
 	URL       string
 	secondary bool
+	// tag is the tag of the release, which the asset belongs to.
+	tag string
 }
 
 // Release provides an interface for a release which contains multiple assets at the same release (TagName)
@@ -151,36 +149,20 @@ func (al assetList) FilterAssets(accept func(string) string) assetList {
 
 func CollectReleaseAssets(p Package, r Release, allReleases map[string][]Release) []Asset {
 	assets := make(assetList, 0, len(r.Assets))
-	assets = append(assets, r.Assets.FilterAssets(p.Primary.Accept(r.TagName))...)
+	for _, asset := range r.Assets.FilterAssets(p.Primary.Accept(r.TagName)) {
+		asset.tag = r.TagName
+		assets = append(assets, asset)
+	}
 	for _, src := range p.Additional {
-		candidates := allReleases[src.String()]
-		sort.Sort(releaseList(candidates))
-		start, end := -1, len(candidates)
-		for i, srcRelease := range candidates {
-			// Collect matching minor versions
-			comp := semver.Compare(semver.MajorMinor(r.TagName), semver.MajorMinor(srcRelease.TagName))
-			if start == -1 && comp == 0 {
-				start = i
-			}
-			if comp > 0 {
-				end = i
-				break
-			}
+		candidate, ok := matchRelease(r, allReleases[src.String()])
+		if !ok {
+			log.Printf("Skipping %s, no release matches %s", src.String(), r.String())
+			continue
 		}
-		candidates = candidates[start:end]
-		timeMatch := len(candidates) - 1
-		for i, srcRelease := range candidates {
-			// TODO: more sophisticated alignment options, for example, always use latest matching minor.
-			if r.Created.After(srcRelease.Created) {
-				timeMatch = i
-				break
-			}
-		}
-
-		candidate := candidates[timeMatch]
 		newAssets := candidate.Assets.FilterAssets(src.Accept(candidate.TagName))
 		for i := range newAssets {
 			newAssets[i].secondary = true
+			newAssets[i].tag = candidate.TagName
 		}
 		assets = append(assets, newAssets...)
 		log.Printf("Using %s/%s with %s/%s", candidate.String(), candidate.TagName, r.String(), r.TagName)
@@ -189,95 +171,120 @@ func CollectReleaseAssets(p Package, r Release, allReleases map[string][]Release
 	return assets
 }
 
-// HandleRelease processes the files for a given release of the specified
-// Package.
-func HandleRelease(ctx context.Context, client *http.Client, p Package, r Release, allReleases map[string][]Release) error {
+// matchRelease returns the release of the candidates with the same minor
+// version as r, which is the newest one created before r, or the oldest one if
+// all of them were created after r. It returns false if no candidate has the
+// same minor version.
+func matchRelease(r Release, candidates []Release) (Release, bool) {
+	sorted := make(releaseList, len(candidates))
+	copy(sorted, candidates)
+	sort.Sort(sorted)
+	start, end := -1, len(sorted)
+	for i, srcRelease := range sorted {
+		// Collect matching minor versions
+		comp := semver.Compare(semver.MajorMinor(r.TagName), semver.MajorMinor(srcRelease.TagName))
+		if start == -1 && comp == 0 {
+			start = i
+		}
+		if comp > 0 {
+			end = i
+			break
+		}
+	}
+	if start == -1 {
+		return Release{}, false
+	}
+	sorted = sorted[start:end]
+	timeMatch := len(sorted) - 1
+	for i, srcRelease := range sorted {
+		// TODO: more sophisticated alignment options, for example, always use latest matching minor.
+		if r.Created.After(srcRelease.Created) {
+			timeMatch = i
+			break
+		}
+	}
+	return sorted[timeMatch], true
+}
+
+// file is an asset to store in a file.
+type file struct {
+	asset Asset
+	// path is the path of the file.
+	path string
+	// lockPath is the path of the file relative to the directory of the
+	// Package, as recorded in the lock file.
+	lockPath string
+}
+
+// releaseFiles returns the files for a given release of the specified Package,
+// under the directory of the Package in root.
+func releaseFiles(root string, p Package, r Release, allReleases map[string][]Release) []file {
 	if p.Alternatives {
-		return handleAlternatives(ctx, client, p, r, allReleases)
+		return handleAlternatives(root, p, r, allReleases)
 	}
-	return handlePrimary(ctx, client, p, r, allReleases)
+	return handlePrimary(root, p, r, allReleases)
 }
 
-// handlePrimary handles the files for a primary-style package.
-func handlePrimary(ctx context.Context, client *http.Client, p Package, r Release, allReleases map[string][]Release) error {
+// handlePrimary returns the files for a primary-style package.
+func handlePrimary(root string, p Package, r Release, allReleases map[string][]Release) []file {
 	assets := CollectReleaseAssets(p, r, allReleases)
 
 	shortName := strings.TrimPrefix(r.TagName, "v")
-	path := filepath.Join("cmd", "operator", "kodata", p.Name, shortName)
-	err := os.MkdirAll(path, 0755)
-	if err != nil {
-		return err
-	}
+	path := filepath.Join(root, p.Name, shortName)
 
-	// Download assets and store them.
+	files := make([]file, 0, len(assets))
 	for i, asset := range assets {
 		fileName := fmt.Sprintf("%d-%s", i+1, asset.Name)
-		file, err := os.OpenFile(filepath.Join(path, fileName), os.O_CREATE|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("Unable to open %s: %w", fileName, err)
-		}
-		defer file.Close()
-		log.Print(asset.URL)
-		fetch, err := client.Get(asset.URL)
-		if err != nil {
-			return fmt.Errorf("Unable to fetch %s: %w", asset.URL, err)
-		}
-		defer fetch.Body.Close()
-		_, err = io.Copy(file, fetch.Body)
-		if err != nil {
-			return fmt.Errorf("Unable to write to %s: %w", fileName, err)
-		}
+		files = append(files, file{
+			asset:    asset,
+			path:     filepath.Join(path, fileName),
+			lockPath: shortName + "/" + fileName,
+		})
 	}
-	return nil
+	return files
 }
 
-func handleAlternatives(ctx context.Context, client *http.Client, p Package, r Release, allReleases map[string][]Release) error {
+// handleAlternatives returns the files for an alternatives-style package.
+func handleAlternatives(root string, p Package, r Release, allReleases map[string][]Release) []file {
 	minor := semver.MajorMinor(r.TagName)
-	if lm := latestMinor(minor, allReleases[p.Primary.String()]); lm.TagName != r.TagName {
+	shortName := strings.TrimPrefix(minor, "v")
+	if p.Patches {
+		shortName = strings.TrimPrefix(r.TagName, "v")
+	} else if lm := latestMinor(minor, allReleases[p.Primary.String()]); lm.TagName != r.TagName {
 		log.Printf("Skipping %q, %q is newer", r.TagName, lm.TagName)
 		return nil
 	}
+	path := filepath.Join(root, p.Name, shortName)
 
-	shortName := strings.TrimPrefix(minor, "v")
-	path := filepath.Join("cmd", "operator", "kodata", p.Name, shortName)
-	err := os.MkdirAll(path, 0755)
-	if err != nil {
-		return err
-	}
-
+	var files []file
 	for _, src := range p.Additional {
 		candidates := allReleases[src.String()]
 		resourcePath := path
+		relativePath := shortName
 		if src.EventingService != "" {
 			resourcePath = filepath.Join(path, src.EventingService)
-			err := os.MkdirAll(resourcePath, 0755)
-			if err != nil {
-				return err
-			}
+			relativePath = shortName + "/" + src.EventingService
 		}
 		release := latestMinor(minor, candidates)
-		// Download assets and concatenate them.
+		if p.Patches {
+			match, ok := matchRelease(r, candidates)
+			if !ok {
+				log.Printf("Skipping %s, no release matches %s", src.String(), r.String())
+				continue
+			}
+			release = match
+		}
 		assets := release.Assets.FilterAssets(src.Accept(release.TagName))
 		for _, a := range assets {
-			fileName := filepath.Join(resourcePath, a.Name)
-			file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY, 0644)
-			if err != nil {
-				return fmt.Errorf("Unable to open %s: %w", fileName, err)
-			}
-			defer file.Close()
-			log.Print(a.URL)
-			fetch, err := client.Get(a.URL)
-			if err != nil {
-				return fmt.Errorf("Unable to fetch %s: %w", a.URL, err)
-			}
-			defer fetch.Body.Close()
-			_, err = io.Copy(file, fetch.Body)
-			if err != nil {
-				return fmt.Errorf("Unable to write to %s: %w", fileName, err)
-			}
+			a.tag = release.TagName
+			files = append(files, file{
+				asset:    a,
+				path:     filepath.Join(resourcePath, a.Name),
+				lockPath: relativePath + "/" + a.Name,
+			})
 		}
 	}
-	return nil
+	return files
 }
 
 // LastN selects the last N minor releases (including all patch releases) for a
@@ -314,6 +321,25 @@ func LastN(latestVersion string, minors int, allReleases []Release) []Release {
 	return retval
 }
 
+// SelectReleases selects the releases, whose tag matches one of the versions,
+// either exactly or by major and minor, e.g. "v0.24" selects all the patch
+// releases of v0.24. The "v" prefix of the versions is optional. The releases
+// are sorted from the newest to the oldest.
+func SelectReleases(versions []string, allReleases []Release) []Release {
+	retval := make(releaseList, 0, len(allReleases))
+	for _, r := range allReleases {
+		for _, v := range versions {
+			v = "v" + strings.TrimPrefix(v, "v")
+			if semver.Compare(v, r.TagName) == 0 || (semver.MajorMinor(v) == v && semver.MajorMinor(r.TagName) == v) {
+				retval = append(retval, r)
+				break
+			}
+		}
+	}
+	sort.Sort(retval)
+	return retval
+}
+
 func latestMinor(minor string, choices []Release) Release {
 	ret := Release{
 		TagName: minor,
@@ -255,6 +255,30 @@ func TestLastN(t *testing.T) {
 	}
 }
 
+func TestSelectReleases(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     []string
+	}{
+		{"exact version", []string{"v0.2.1"}, []string{"v0.2.1"}},
+		{"minor version", []string{"v0.3"}, []string{"v0.3.2", "v0.3.1", "v0.3"}},
+		{"without prefix", []string{"0.5", "0.2.8"}, []string{"v0.5.1", "v0.5", "v0.2.8"}},
+		{"unknown version", []string{"v0.6"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, r := range SelectReleases(tt.versions, orderedReleases) {
+				got = append(got, r.TagName)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SelectReleases(%v) (-want +got): %s", tt.versions, diff)
+			}
+		})
+	}
+}
+
 func TestCollectReleaseAssets(t *testing.T) {
 	p := Package{
 		Name: "test",
@@ -383,3 +407,59 @@ func TestCollectReleaseAssets(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleAlternativesPatches(t *testing.T) {
+	allReleases := map[string][]Release{
+		"knative/serving": {
+			{TagName: "v0.2.0", Created: time.Unix(2000, 0)},
+			{TagName: "v0.2.1", Created: time.Unix(3000, 0)},
+		},
+		"knative/net-istio": {
+			{TagName: "v0.2.0", Created: time.Unix(2100, 0), Assets: []Asset{{Name: "net-istio.yaml", URL: "data:istio020"}}},
+			{TagName: "v0.2.1", Created: time.Unix(2900, 0), Assets: []Asset{{Name: "net-istio.yaml", URL: "data:istio021"}}},
+			{TagName: "v0.2.2", Created: time.Unix(3100, 0), Assets: []Asset{{Name: "net-istio.yaml", URL: "data:istio022"}}},
+		},
+		"knative/net-kourier": {
+			{TagName: "v0.1.0", Created: time.Unix(1000, 0), Assets: []Asset{{Name: "kourier.yaml", URL: "data:kourier010"}}},
+		},
+	}
+	p := Package{
+		Name:         "ingress",
+		Alternatives: true,
+		Primary:      Source{GitHub: GitHubSource{Repo: "knative/serving"}},
+		Additional: []Source{
+			{GitHub: GitHubSource{Repo: "knative/net-istio"}},
+			{GitHub: GitHubSource{Repo: "knative/net-kourier"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		patches bool
+		release string
+		want    []string
+	}{
+		{"latest minor", false, "v0.2.1", []string{"0.2/net-istio.yaml data:istio022"}},
+		{"older patch skipped", false, "v0.2.0", nil},
+		{"first patch", true, "v0.2.0", []string{"0.2.0/net-istio.yaml data:istio020"}},
+		{"second patch", true, "v0.2.1", []string{"0.2.1/net-istio.yaml data:istio021"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.Patches = tt.patches
+			var r Release
+			for _, release := range allReleases["knative/serving"] {
+				if release.TagName == tt.release {
+					r = release
+				}
+			}
+			var got []string
+			for _, f := range handleAlternatives("", p, r, allReleases) {
+				got = append(got, f.lockPath+" "+f.asset.URL)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("handleAlternatives() (-want +got): %s", diff)
+			}
+		})
+	}
+}
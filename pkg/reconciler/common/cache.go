@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/pkg/metrics"
+)
+
+const (
+	// defaultCacheSize is the maximum number of manifests kept in the cache.
+	defaultCacheSize = 64
+	// defaultCacheTTL is the maximum time a manifest is kept in the cache.
+	defaultCacheTTL = time.Hour
+
+	cacheHit  = "hit"
+	cacheMiss = "miss"
+)
+
+var (
+	cache = newManifestCache(defaultCacheSize, defaultCacheTTL)
+
+	cacheLookupsM = stats.Int64(
+		"manifest_cache_lookups",
+		"Number of lookups in the manifest cache",
+		stats.UnitDimensionless)
+	componentTagKey = tag.MustNewKey("component")
+	resultTagKey    = tag.MustNewKey("result")
+)
+
+func init() {
+	if err := metrics.RegisterResourceView(&view.View{
+		Description: cacheLookupsM.Description(),
+		Measure:     cacheLookupsM,
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{componentTagKey, resultTagKey},
+	}); err != nil {
+		panic(err)
+	}
+}
+
+// cacheKey identifies a manifest in the cache. Manifests are namespaced per
+// component, so that the entries of one CR can be evicted without affecting
+// the others.
+type cacheKey struct {
+	component string
+	path      string
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	manifest mf.Manifest
+	expires  time.Time
+}
+
+// manifestCache is a concurrency-safe LRU cache of manifests, bounded in both
+// the number of entries and the age of each entry.
+type manifestCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	now     func() time.Time
+	lru     *list.List
+	entries map[cacheKey]*list.Element
+}
+
+func newManifestCache(size int, ttl time.Duration) *manifestCache {
+	return &manifestCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		lru:     list.New(),
+		entries: make(map[cacheKey]*list.Element, size),
+	}
+}
+
+// get returns the manifest saved under the key, if it is available and not expired.
+func (c *manifestCache) get(key cacheKey) (mf.Manifest, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return mf.Manifest{}, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.now().After(entry.expires) {
+		c.remove(elem)
+		return mf.Manifest{}, false
+	}
+	c.lru.MoveToFront(elem)
+	return entry.manifest, true
+}
+
+// set saves the manifest under the key, evicting the least recently used entry
+// if the cache is full.
+func (c *manifestCache) set(key cacheKey, manifest mf.Manifest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.manifest, entry.expires = manifest, expires
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, manifest: manifest, expires: expires})
+	for c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+// evict removes all the entries saved for the component.
+func (c *manifestCache) evict(component string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if key.component == component {
+			c.remove(elem)
+		}
+	}
+}
+
+// len returns the number of entries in the cache.
+func (c *manifestCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// remove must be called with the lock held.
+func (c *manifestCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*cacheEntry).key)
+}
+
+// cacheNamespace returns the namespace of the cache entries for the component.
+func cacheNamespace(instance v1alpha1.KComponent) string {
+	return fmt.Sprintf("%s/%s/%s", componentKind(instance), instance.GetNamespace(), instance.GetName())
+}
+
+func componentKind(instance v1alpha1.KComponent) string {
+	switch instance.(type) {
+	case *v1alpha1.KnativeServing:
+		return "KnativeServing"
+	case *v1alpha1.KnativeEventing:
+		return "KnativeEventing"
+	}
+	return ""
+}
+
+func recordCacheLookup(instance v1alpha1.KComponent, result string) {
+	ctx, err := tag.New(context.Background(),
+		tag.Insert(componentTagKey, componentKind(instance)),
+		tag.Insert(resultTagKey, result))
+	if err != nil {
+		return
+	}
+	metrics.Record(ctx, cacheLookupsM.M(1))
+}
+
+// EvictCache removes all the manifests saved in the cache for the component.
+// It is meant to be called when the component is deleted.
+func EvictCache(instance v1alpha1.KComponent) {
+	cache.evict(cacheNamespace(instance))
+}
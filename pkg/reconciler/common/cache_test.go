@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestCache(t *testing.T) {
+	expectedPath := "testdata/kodata/knative-serving/0.16.1/"
+	manifest, err := mf.NewManifest(expectedPath)
+	util.AssertEqual(t, err, nil)
+
+	c := newManifestCache(2, time.Minute)
+	c.set(cacheKey{component: "a", path: "1"}, manifest)
+	util.AssertEqual(t, c.len(), 1)
+	m, ok := c.get(cacheKey{component: "a", path: "1"})
+	util.AssertEqual(t, ok, true)
+	util.AssertEqual(t, util.DeepMatchWithPath(m, expectedPath), true)
+
+	// The same path is namespaced per component.
+	_, ok = c.get(cacheKey{component: "b", path: "1"})
+	util.AssertEqual(t, ok, false)
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newManifestCache(2, time.Minute)
+	c.set(cacheKey{component: "a", path: "1"}, mf.Manifest{})
+	c.set(cacheKey{component: "a", path: "2"}, mf.Manifest{})
+	// Touch the first entry, so that the second one is the least recently used.
+	c.get(cacheKey{component: "a", path: "1"})
+	c.set(cacheKey{component: "a", path: "3"}, mf.Manifest{})
+
+	util.AssertEqual(t, c.len(), 2)
+	_, ok := c.get(cacheKey{component: "a", path: "1"})
+	util.AssertEqual(t, ok, true)
+	_, ok = c.get(cacheKey{component: "a", path: "2"})
+	util.AssertEqual(t, ok, false)
+	_, ok = c.get(cacheKey{component: "a", path: "3"})
+	util.AssertEqual(t, ok, true)
+}
+
+func TestCacheExpiresEntries(t *testing.T) {
+	now := time.Now()
+	c := newManifestCache(2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.set(cacheKey{component: "a", path: "1"}, mf.Manifest{})
+
+	now = now.Add(30 * time.Second)
+	_, ok := c.get(cacheKey{component: "a", path: "1"})
+	util.AssertEqual(t, ok, true)
+
+	now = now.Add(time.Minute)
+	_, ok = c.get(cacheKey{component: "a", path: "1"})
+	util.AssertEqual(t, ok, false)
+	util.AssertEqual(t, c.len(), 0)
+}
+
+func TestEvictCache(t *testing.T) {
+	serving := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+	}
+	eventing := &v1alpha1.KnativeEventing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-eventing", Namespace: "knative-eventing"},
+	}
+	path := "testdata/kodata/knative-serving/0.16.1/"
+	_, err := FetchManifest(context.TODO(), serving, path)
+	util.AssertEqual(t, err, nil)
+	_, err = FetchManifest(context.TODO(), eventing, path)
+	util.AssertEqual(t, err, nil)
+
+	EvictCache(serving)
+	_, ok := cache.get(cacheKey{component: cacheNamespace(serving), path: path})
+	util.AssertEqual(t, ok, false)
+	_, ok = cache.get(cacheKey{component: cacheNamespace(eventing), path: path})
+	util.AssertEqual(t, ok, true)
+	EvictCache(eventing)
+}
+
+func TestCacheConcurrentAccess(t *testing.T) {
+	c := newManifestCache(8, time.Minute)
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			component := fmt.Sprintf("component-%d", i%2)
+			for j := 0; j < 100; j++ {
+				key := cacheKey{component: component, path: fmt.Sprintf("%d", j%10)}
+				c.set(key, mf.Manifest{})
+				c.get(key)
+			}
+			c.evict(component)
+		}(i)
+	}
+	wg.Wait()
+	util.AssertEqual(t, c.len() <= 8, true)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/mod/semver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/pkg/configmap"
+	"knative.dev/pkg/logging"
+	"knative.dev/pkg/system"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// KoDataExtraPathsEnvKey is the key of the environment variable to specify the extra directories,
+	// e.g. mounted from volumes, which have the same layout as the ko data directory. The directories
+	// are separated by the path list separator, and take precedence over the ko data directory.
+	KoDataExtraPathsEnvKey = "KO_DATA_EXTRA_PATHS"
+
+	// CatalogConfigMapName is the name of the ConfigMap in the namespace of the operator, which lists
+	// the versions available in the cluster, along with the URLs of their manifests. The versions in
+	// this ConfigMap take precedence over the ones in the directories.
+	CatalogConfigMapName = "config-catalog"
+
+	// IngressCatalog is the name of the catalog entries of the ingresses.
+	IngressCatalog = "ingress"
+	// SourceCatalog is the name of the catalog entries of the eventing sources.
+	SourceCatalog = "eventing-source"
+	// CertManagerCatalog is the name of the catalog entries of net-certmanager.
+	CertManagerCatalog = "net-certmanager"
+	// DefaultDomainCatalog is the name of the catalog entries of the default-domain Job of Knative Serving.
+	DefaultDomainCatalog = "default-domain"
+)
+
+// catalog is the version catalog of the operator.
+var catalog = &versionCatalog{}
+
+// versionCatalog lists the versions of the Knative components, the ingresses and the eventing sources
+// available to the operator. The versions come from the ko data directory, the extra directories and
+// the in-cluster catalog ConfigMap.
+type versionCatalog struct {
+	mu sync.RWMutex
+	// cluster maps the name of the entries to the versions, and the versions to the URLs of the
+	// manifests per sub-entry, e.g. per eventing source. The sub-entry is empty for the others.
+	cluster map[string]map[string]map[string][]string
+}
+
+// dirs returns the directories of the catalog, in the order of precedence.
+func (c *versionCatalog) dirs() []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(KoDataExtraPathsEnvKey)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, os.Getenv(KoEnvKey))
+}
+
+// releases returns the versions available for the name, sorted in a descending order.
+func (c *versionCatalog) releases(name string) ([]string, error) {
+	seen := map[string]bool{}
+	var releases []string
+	add := func(version string) {
+		if !seen[version] {
+			seen[version] = true
+			releases = append(releases, version)
+		}
+	}
+
+	c.mu.RLock()
+	for version := range c.cluster[name] {
+		add(version)
+	}
+	c.mu.RUnlock()
+
+	for _, dir := range c.dirs() {
+		files, err := ioutil.ReadDir(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, file := range files {
+			if isDir(filepath.Join(dir, name, file.Name())) {
+				add(file.Name())
+			}
+		}
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("unable to find any version of %s in the catalog", name)
+	}
+
+	// This function makes sure the versions are sorted in a descending order.
+	sort.Slice(releases, func(i, j int) bool {
+		// The index i is the one after the index j. If i is more recent than j, return true to swap.
+		return semver.Compare(SanitizeSemver(releases[i]), SanitizeSemver(releases[j])) == 1
+	})
+	return releases, nil
+}
+
+// manifests returns the comma-separated paths or URLs of the manifests for the name, the version
+// and the sub-entry, which is empty for the entries without any.
+func (c *versionCatalog) manifests(name, version, sub string) (string, error) {
+	c.mu.RLock()
+	urls, ok := c.cluster[name][version][sub]
+	c.mu.RUnlock()
+	if ok {
+		return strings.Join(urls, COMMA), nil
+	}
+
+	for _, dir := range c.dirs() {
+		if p := filepath.Join(dir, name, version, sub); isDir(p) {
+			return p, nil
+		}
+	}
+	if sub != "" {
+		name = name + "/" + sub
+	}
+	return "", fmt.Errorf("the version %s of %s is not available in the catalog", version, name)
+}
+
+// setCluster replaces the in-cluster versions with the ones listed in the ConfigMap.
+func (c *versionCatalog) setCluster(cm *corev1.ConfigMap) error {
+	cluster := make(map[string]map[string]map[string][]string, len(cm.Data))
+	for name, data := range cm.Data {
+		versions := map[string]map[string][]string{}
+		if name == SourceCatalog {
+			if err := yaml.Unmarshal([]byte(data), &versions); err != nil {
+				return fmt.Errorf("failed to parse %s in the catalog: %w", name, err)
+			}
+		} else {
+			var urls map[string][]string
+			if err := yaml.Unmarshal([]byte(data), &urls); err != nil {
+				return fmt.Errorf("failed to parse %s in the catalog: %w", name, err)
+			}
+			for version, u := range urls {
+				versions[version] = map[string][]string{"": u}
+			}
+		}
+		cluster[name] = versions
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cluster = cluster
+	return nil
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
+
+// CatalogReleases returns the versions available in the catalog for the name, e.g. IngressCatalog,
+// sorted in a descending order.
+func CatalogReleases(name string) ([]string, error) {
+	return catalog.releases(name)
+}
+
+// CatalogManifests returns the comma-separated paths or URLs of the manifests in the catalog for the
+// name, the version and the sub-entry, e.g. the name of an eventing source. The sub-entry is empty for
+// the entries without any.
+func CatalogManifests(name, version, sub string) (string, error) {
+	return catalog.manifests(name, version, sub)
+}
+
+// SetClusterCatalog replaces the in-cluster versions of the catalog with the ones listed in the
+// catalog ConfigMap. A nil ConfigMap removes all of them.
+func SetClusterCatalog(cm *corev1.ConfigMap) error {
+	if cm == nil {
+		cm = &corev1.ConfigMap{}
+	}
+	return catalog.setCluster(cm)
+}
+
+// WatchCatalog watches the catalog ConfigMap in the namespace of the operator with the ConfigMap
+// watcher of the controllers, which is not restricted to the manifest sources, so the catalog
+// needs no label. The in-cluster versions of the catalog are updated when the ConfigMap changes,
+// and then resync is called. The catalog is optional, and deleting it clears them.
+func WatchCatalog(ctx context.Context, cmw configmap.Watcher, resync func()) {
+	logger := logging.FromContext(ctx)
+	update := func(cm *corev1.ConfigMap) {
+		if err := SetClusterCatalog(cm); err != nil {
+			logger.Error("Failed to update the version catalog", err)
+			return
+		}
+		resync()
+	}
+	if dw, ok := cmw.(configmap.DefaultingWatcher); ok {
+		dw.WatchWithDefault(corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: CatalogConfigMapName, Namespace: system.Namespace()},
+		}, update)
+		return
+	}
+	cmw.Watch(CatalogConfigMapName, update)
+}
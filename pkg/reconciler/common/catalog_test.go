@@ -0,0 +1,267 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	"knative.dev/pkg/configmap"
+)
+
+const clusterCatalog = `
+0.17.0:
+- https://example.com/serving/v0.17.0/serving-crds.yaml
+- https://example.com/serving/v0.17.0/serving-core.yaml
+`
+
+func TestCatalog(t *testing.T) {
+	os.Setenv(KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(KoEnvKey)
+	os.Setenv(KoDataExtraPathsEnvKey, "testdata/missing:testdata/kodata-extra")
+	defer os.Unsetenv(KoDataExtraPathsEnvKey)
+	defer SetClusterCatalog(nil)
+
+	err := SetClusterCatalog(&corev1.ConfigMap{
+		Data: map[string]string{
+			"knative-serving": clusterCatalog,
+			SourceCatalog: `
+"0.17":
+  kafka:
+  - https://example.com/eventing-kafka/v0.17.0/source.yaml
+`,
+		},
+	})
+	util.AssertEqual(t, err, nil)
+
+	releases, err := CatalogReleases("knative-serving")
+	util.AssertEqual(t, err, nil)
+	util.AssertDeepEqual(t, releases, []string{"0.17.0", "0.16.2", "0.16.1", "0.16.0", "0.15.0", "0.14.0", "latest"})
+
+	_, err = CatalogReleases("missing")
+	util.AssertEqual(t, err.Error(), "unable to find any version of missing in the catalog")
+
+	tests := []struct {
+		name     string
+		entry    string
+		version  string
+		sub      string
+		expected string
+		err      string
+	}{{
+		name:    "in-cluster catalog",
+		entry:   "knative-serving",
+		version: "0.17.0",
+		expected: "https://example.com/serving/v0.17.0/serving-crds.yaml," +
+			"https://example.com/serving/v0.17.0/serving-core.yaml",
+	}, {
+		name:     "in-cluster catalog with a sub-entry",
+		entry:    SourceCatalog,
+		version:  "0.17",
+		sub:      "kafka",
+		expected: "https://example.com/eventing-kafka/v0.17.0/source.yaml",
+	}, {
+		name:     "extra directory",
+		entry:    "knative-serving",
+		version:  "0.16.2",
+		expected: "testdata/kodata-extra/knative-serving/0.16.2",
+	}, {
+		name:     "extra directory takes precedence over the ko data directory",
+		entry:    "knative-serving",
+		version:  "0.16.1",
+		expected: "testdata/kodata-extra/knative-serving/0.16.1",
+	}, {
+		name:     "ko data directory",
+		entry:    "knative-serving",
+		version:  "0.16.0",
+		expected: "testdata/kodata/knative-serving/0.16.0",
+	}, {
+		name:    "missing version",
+		entry:   "knative-serving",
+		version: "0.12.0",
+		err:     "the version 0.12.0 of knative-serving is not available in the catalog",
+	}, {
+		name:    "missing sub-entry",
+		entry:   SourceCatalog,
+		version: "0.17",
+		sub:     "redis",
+		err:     "the version 0.17 of eventing-source/redis is not available in the catalog",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifests, err := CatalogManifests(test.entry, test.version, test.sub)
+			if test.err != "" {
+				util.AssertEqual(t, err.Error(), test.err)
+				return
+			}
+			util.AssertEqual(t, err, nil)
+			util.AssertEqual(t, manifests, test.expected)
+		})
+	}
+}
+
+func TestSetClusterCatalogInvalid(t *testing.T) {
+	defer SetClusterCatalog(nil)
+
+	err := SetClusterCatalog(&corev1.ConfigMap{
+		Data: map[string]string{"knative-serving": "0.17.0: not a list"},
+	})
+	util.AssertEqual(t, err != nil, true)
+}
+
+func TestEmptyCatalog(t *testing.T) {
+	os.Setenv(KoEnvKey, "testdata/missing")
+	defer os.Unsetenv(KoEnvKey)
+
+	// Missing versions are reported as errors instead of panics.
+	instance := &v1alpha1.KnativeServing{}
+	util.AssertEqual(t, TargetVersion(instance), "")
+	err := IsVersionValidMigrationEligible(instance)
+	util.AssertEqual(t, err.Error(), "no version of knative-serving is available in the catalog")
+	_, err = TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err != nil, true)
+
+	instance.Spec.Version = "0.16"
+	util.AssertEqual(t, TargetVersion(instance), "0.16")
+	_, err = TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err != nil, true)
+	_, err = AlternativeManifests(IngressCatalog, "0.16.0", "")
+	util.AssertEqual(t, err.Error(), "the version 0.16 of ingress is not available in the catalog")
+}
+
+func TestAlternativeManifests(t *testing.T) {
+	os.Setenv(KoEnvKey, "testdata/missing")
+	defer os.Unsetenv(KoEnvKey)
+	defer SetClusterCatalog(nil)
+
+	err := SetClusterCatalog(&corev1.ConfigMap{
+		Data: map[string]string{
+			IngressCatalog: `
+"0.17": [https://example.com/0.17/net-istio.yaml]
+0.17.1: [https://example.com/0.17.1/net-istio.yaml]
+0.18.0: [https://example.com/0.18.0/net-istio.yaml]
+0.18.2: [https://example.com/0.18.2/net-istio.yaml]
+latest: [https://example.com/latest/net-istio.yaml]
+`,
+			SourceCatalog: `
+0.18.0:
+  kafka: [https://example.com/0.18.0/kafka.yaml]
+0.18.2:
+  github: [https://example.com/0.18.2/github.yaml]
+`,
+		},
+	})
+	util.AssertEqual(t, err, nil)
+
+	tests := []struct {
+		name     string
+		entry    string
+		version  string
+		sub      string
+		expected string
+		err      string
+	}{{
+		name:     "same patch version",
+		entry:    IngressCatalog,
+		version:  "0.17.1",
+		expected: "https://example.com/0.17.1/net-istio.yaml",
+	}, {
+		name:     "same major.minor version",
+		entry:    IngressCatalog,
+		version:  "0.17.0",
+		expected: "https://example.com/0.17/net-istio.yaml",
+	}, {
+		name:     "latest patch version of the same major.minor version",
+		entry:    IngressCatalog,
+		version:  "0.18.1",
+		expected: "https://example.com/0.18.2/net-istio.yaml",
+	}, {
+		name:     "major.minor version without any patch",
+		entry:    IngressCatalog,
+		version:  "0.18",
+		expected: "https://example.com/0.18.2/net-istio.yaml",
+	}, {
+		name:     "latest version",
+		entry:    IngressCatalog,
+		version:  "latest",
+		expected: "https://example.com/latest/net-istio.yaml",
+	}, {
+		name:     "sub-entry missing in the same patch version",
+		entry:    SourceCatalog,
+		version:  "0.18.2",
+		sub:      "kafka",
+		expected: "https://example.com/0.18.0/kafka.yaml",
+	}, {
+		name:    "missing major.minor version",
+		entry:   IngressCatalog,
+		version: "0.19.0",
+		err:     "the version 0.19 of ingress is not available in the catalog",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifests, err := AlternativeManifests(test.entry, test.version, test.sub)
+			if test.err != "" {
+				util.AssertEqual(t, err.Error(), test.err)
+				return
+			}
+			util.AssertEqual(t, err, nil)
+			util.AssertEqual(t, manifests, test.expected)
+		})
+	}
+}
+
+func TestWatchCatalog(t *testing.T) {
+	defer SetClusterCatalog(nil)
+
+	resynced := 0
+	cmw := &configmap.ManualWatcher{Namespace: "knative-operator"}
+	WatchCatalog(context.TODO(), cmw, func() {
+		resynced++
+	})
+
+	// The ConfigMaps other than the catalog are ignored.
+	cmw.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "knative-operator"},
+		Data:       map[string]string{"knative-serving": clusterCatalog},
+	})
+	util.AssertEqual(t, resynced, 0)
+	_, err := CatalogManifests("knative-serving", "0.17.0", "")
+	util.AssertEqual(t, err != nil, true)
+
+	// The catalog needs no label.
+	cmw.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CatalogConfigMapName, Namespace: "knative-operator"},
+		Data:       map[string]string{"knative-serving": clusterCatalog},
+	})
+	util.AssertEqual(t, resynced, 1)
+	_, err = CatalogManifests("knative-serving", "0.17.0", "")
+	util.AssertEqual(t, err, nil)
+
+	cmw.OnChange(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: CatalogConfigMapName, Namespace: "knative-operator"},
+	})
+	util.AssertEqual(t, resynced, 2)
+	_, err = CatalogManifests("knative-serving", "0.17.0", "")
+	util.AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// Dependency is a kind of resource, which has to be served by the cluster before a component
+// can be installed, e.g. the Gateway of Istio.
+type Dependency struct {
+	// Name is the name of the provider of the dependency, e.g. Istio.
+	Name string
+	// Groups are the API groups, which may serve the kind, e.g. networking.istio.io. Any served
+	// version of any of the groups satisfies the dependency.
+	Groups []string
+	// Kind is the kind of the resource, e.g. Gateway.
+	Kind string
+}
+
+// DependencyLister lists the dependencies of the instance.
+type DependencyLister func(instance v1alpha1.KComponent) []Dependency
+
+// CheckDependencies returns a Stage, which verifies with the API discovery that the dependencies
+// of the instance are served. It reports the missing ones in the status, and returns an error so
+// that the instance is requeued until they are installed. The discovery is cached across the
+// reconciliations, and only invalidated when a dependency is missing from the cached one.
+func CheckDependencies(client discovery.CachedDiscoveryInterface, list DependencyLister) Stage {
+	return func(_ context.Context, _ *mf.Manifest, instance v1alpha1.KComponent) error {
+		status := instance.GetStatus()
+		deps := list(instance)
+		if len(deps) == 0 {
+			status.MarkDependenciesInstalled()
+			return nil
+		}
+		names, missing, err := missingDependencies(client, deps)
+		if err == nil && len(names) != 0 {
+			// The cached discovery may predate the installation of the dependencies.
+			client.Invalidate()
+			names, missing, err = missingDependencies(client, deps)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to discover the served resources: %w", err)
+		}
+		if len(names) == 0 {
+			status.MarkDependenciesInstalled()
+			return nil
+		}
+		messages := make([]string, 0, len(names))
+		for _, name := range names {
+			messages = append(messages, fmt.Sprintf("%s (%s)", name, strings.Join(missing[name], ", ")))
+		}
+		msg := strings.Join(messages, ", ")
+		status.MarkDependencyMissing(msg)
+		return fmt.Errorf("missing dependencies: %s", msg)
+	}
+}
+
+// missingDependencies returns the names of the providers of the dependencies, which are not
+// served, in their order, along with the missing kinds by the names.
+func missingDependencies(client discovery.DiscoveryInterface, deps []Dependency) ([]string, map[string][]string, error) {
+	served, err := servedKinds(client)
+	if err != nil {
+		return nil, nil, err
+	}
+	missing := map[string][]string{}
+	var names []string
+	for _, dep := range deps {
+		if isServed(served, dep) {
+			continue
+		}
+		if _, ok := missing[dep.Name]; !ok {
+			names = append(names, dep.Name)
+		}
+		missing[dep.Name] = append(missing[dep.Name], dep.Groups[0]+"/"+dep.Kind)
+	}
+	return names, missing, nil
+}
+
+// servedKinds returns the kinds served by the cluster, by their API groups.
+func servedKinds(client discovery.DiscoveryInterface) (map[string]sets.String, error) {
+	_, lists, err := client.ServerGroupsAndResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+	served := map[string]sets.String{}
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		if served[gv.Group] == nil {
+			served[gv.Group] = sets.NewString()
+		}
+		for _, resource := range list.APIResources {
+			served[gv.Group].Insert(resource.Kind)
+		}
+	}
+	return served, nil
+}
+
+func isServed(served map[string]sets.String, dep Dependency) bool {
+	for _, group := range dep.Groups {
+		if served[group].Has(dep.Kind) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestCheckDependencies(t *testing.T) {
+	client := memory.NewMemCacheClient(&fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{
+		Resources: []*metav1.APIResourceList{{
+			GroupVersion: "networking.istio.io/v1alpha3",
+			APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway"}},
+		}, {
+			GroupVersion: "networking.x-k8s.io/v1alpha1",
+			APIResources: []metav1.APIResource{{Name: "httproutes", Kind: "HTTPRoute"}},
+		}},
+	}})
+
+	tests := []struct {
+		name     string
+		deps     []Dependency
+		expected corev1.ConditionStatus
+		message  string
+	}{{
+		name:     "No dependencies",
+		expected: corev1.ConditionTrue,
+	}, {
+		name:     "Served dependency",
+		deps:     []Dependency{{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "Gateway"}},
+		expected: corev1.ConditionTrue,
+	}, {
+		name: "Dependency served by an alternative group",
+		deps: []Dependency{{
+			Name:   "Gateway API",
+			Groups: []string{"gateway.networking.k8s.io", "networking.x-k8s.io"},
+			Kind:   "HTTPRoute",
+		}},
+		expected: corev1.ConditionTrue,
+	}, {
+		name: "Missing dependencies",
+		deps: []Dependency{
+			{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "Gateway"},
+			{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "VirtualService"},
+			{Name: "Contour", Groups: []string{"projectcontour.io"}, Kind: "HTTPProxy"},
+		},
+		expected: corev1.ConditionFalse,
+		message:  "Dependency missing: Istio (networking.istio.io/VirtualService), Contour (projectcontour.io/HTTPProxy)",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instance := &v1alpha1.KnativeServing{}
+			instance.Status.InitializeConditions()
+			stage := CheckDependencies(client, func(v1alpha1.KComponent) []Dependency {
+				return test.deps
+			})
+			err := stage(context.TODO(), nil, instance)
+			util.AssertEqual(t, err != nil, test.expected == corev1.ConditionFalse)
+
+			condition := instance.Status.GetCondition(v1alpha1.DependenciesInstalled)
+			util.AssertEqual(t, condition.Status, test.expected)
+			util.AssertEqual(t, condition.Message, test.message)
+		})
+	}
+}
+
+func TestCheckDependenciesCachedDiscovery(t *testing.T) {
+	apps := &metav1.APIResourceList{
+		GroupVersion: "apps/v1",
+		APIResources: []metav1.APIResource{{Name: "deployments", Kind: "Deployment"}},
+	}
+	fake := &fakediscovery.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{apps}}}
+	client := memory.NewMemCacheClient(fake)
+	stage := CheckDependencies(client, func(v1alpha1.KComponent) []Dependency {
+		return []Dependency{{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "Gateway"}}
+	})
+
+	// The missing dependency is discovered again before it is reported, i.e. the groups and the
+	// resources of apps/v1 are listed twice.
+	instance := &v1alpha1.KnativeServing{}
+	util.AssertEqual(t, stage(context.TODO(), nil, instance) != nil, true)
+	util.AssertEqual(t, len(fake.Actions()), 4)
+
+	// A dependency installed afterwards is found once the cache is invalidated.
+	fake.Resources = append(fake.Resources, &metav1.APIResourceList{
+		GroupVersion: "networking.istio.io/v1alpha3",
+		APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway"}},
+	})
+	util.AssertEqual(t, stage(context.TODO(), nil, instance), nil)
+	discovered := len(fake.Actions())
+
+	// The served dependency is checked against the cached discovery.
+	util.AssertEqual(t, stage(context.TODO(), nil, instance), nil)
+	util.AssertEqual(t, len(fake.Actions()), discovered)
+}
@@ -0,0 +1,306 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+const (
+	// fetchTimeout is the timeout of a single request for a remote manifest.
+	fetchTimeout = 30 * time.Second
+
+	// tokenKey is the key of the bearer token in the credentials Secret of a manifest.
+	tokenKey = "token"
+)
+
+// fetchBackoff is the backoff between the attempts to fetch a remote manifest.
+var fetchBackoff = wait.Backoff{
+	Steps:    4,
+	Duration: 500 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// retriableError wraps the failures, which are worth retrying.
+type retriableError struct {
+	error
+}
+
+// fetchManifest returns the manifest for the comma-separated path. Every URL in the path is
+// fetched separately, so that the options set on the entries of spec.manifests or
+// spec.additionalManifests can be applied to them.
+func fetchManifest(ctx context.Context, instance v1alpha1.KComponent, path string) (mf.Manifest, error) {
+	entries := manifestEntries(instance)
+	result, _ := mf.ManifestFrom(mf.Slice{})
+	for _, u := range strings.Split(path, COMMA) {
+		m, err := fetchManifestURL(ctx, instance, u, entries[u])
+		if err != nil {
+			return mf.Manifest{}, err
+		}
+		result = result.Append(m)
+	}
+	return result, nil
+}
+
+// fetchManifestURL returns the manifest for a single URL. If the entry specifies a digest,
+// the content of the URL has to match it.
+func fetchManifestURL(ctx context.Context, instance v1alpha1.KComponent, u string, entry v1alpha1.Manifest) (mf.Manifest, error) {
+	var data []byte
+	var err error
+	switch {
+	case isKustomize(u):
+		data, err = readKustomize(ctx, instance, u, entry)
+	case isOCI(u):
+		data, err = readOCI(ctx, instance, u, entry)
+	case isSource(u):
+		data, err = readSource(ctx, instance, u)
+		if err == nil && entry.Sha256 != "" {
+			err = verifyDigest(u, data, entry.Sha256)
+		}
+	case !isURL(u) && entry.Sha256 == "":
+		// Local paths may point to directories, which are left to manifestival.
+		return mf.NewManifest(u)
+	default:
+		data, err = readURL(ctx, instance, u, entry)
+		if err == nil && entry.Sha256 != "" {
+			err = verifyDigest(u, data, entry.Sha256)
+		}
+	}
+	if err != nil {
+		return mf.Manifest{}, err
+	}
+	return mf.ManifestFrom(mf.Reader(bytes.NewReader(data)))
+}
+
+// readURL returns the raw content of a remote URL or a local file.
+func readURL(ctx context.Context, instance v1alpha1.KComponent, u string, entry v1alpha1.Manifest) ([]byte, error) {
+	if !isURL(u) {
+		return ioutil.ReadFile(u)
+	}
+	client, err := httpClient(ctx, instance, entry)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := setCredentials(ctx, instance, entry, req); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	err = retry.OnError(fetchBackoff, func(err error) bool {
+		_, ok := err.(retriableError)
+		return ok
+	}, func() error {
+		var err error
+		data, err = doRequest(client, req)
+		return err
+	})
+	if rerr, ok := err.(retriableError); ok {
+		return nil, rerr.error
+	}
+	return data, err
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+			return nil, retriableError{err}
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("failed to fetch the manifest %s: %s", req.URL, resp.Status)
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+			return nil, retriableError{err}
+		}
+		return nil, err
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// httpClient returns the client to fetch the manifest with. If the entry references a CA
+// bundle ConfigMap, the certificates in it are trusted instead of the system ones. The ConfigMap
+// has to be labelled as a manifest source.
+func httpClient(ctx context.Context, instance v1alpha1.KComponent, entry v1alpha1.Manifest) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if entry.CABundleConfigMap != nil {
+		cm, err := kubeclient.Get(ctx).CoreV1().ConfigMaps(instance.GetNamespace()).Get(ctx,
+			entry.CABundleConfigMap.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the CA bundle ConfigMap %s: %w", entry.CABundleConfigMap.Name, err)
+		}
+		if !isManifestSource(cm.ObjectMeta) {
+			return nil, fmt.Errorf("the CA bundle ConfigMap %s is not labelled with %s", entry.CABundleConfigMap.Name, ManifestSourceSelector)
+		}
+		pool := x509.NewCertPool()
+		for _, bundle := range cm.Data {
+			pool.AppendCertsFromPEM([]byte(bundle))
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport, Timeout: fetchTimeout}, nil
+}
+
+// setCredentials adds the credentials in the Secret referenced by the entry to the request.
+// The Secret contains either the token for bearer authentication, or the username and
+// password for basic authentication. Only a Secret labelled as a manifest source is read, so
+// that the users, who can only create the instance, cannot send any Secret of the namespace to
+// a URL of their choice.
+func setCredentials(ctx context.Context, instance v1alpha1.KComponent, entry v1alpha1.Manifest, req *http.Request) error {
+	if entry.CredentialsSecret == nil {
+		return nil
+	}
+	secret, err := kubeclient.Get(ctx).CoreV1().Secrets(instance.GetNamespace()).Get(ctx,
+		entry.CredentialsSecret.Name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get the credentials Secret %s: %w", entry.CredentialsSecret.Name, err)
+	}
+	if !isManifestSource(secret.ObjectMeta) {
+		return fmt.Errorf("the credentials Secret %s is not labelled with %s", entry.CredentialsSecret.Name, ManifestSourceSelector)
+	}
+	if token, ok := secret.Data[tokenKey]; ok {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+		return nil
+	}
+	username, hasUsername := secret.Data[corev1.BasicAuthUsernameKey]
+	password, hasPassword := secret.Data[corev1.BasicAuthPasswordKey]
+	if !hasUsername || !hasPassword {
+		return fmt.Errorf("the credentials Secret %s should contain either %q, or %q and %q",
+			entry.CredentialsSecret.Name, tokenKey, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+	req.SetBasicAuth(string(username), string(password))
+	return nil
+}
+
+// isURL checks whether the path is a remote URL rather than a local file, in the same way
+// as manifestival does.
+func isURL(path string) bool {
+	if _, err := os.Lstat(path); err == nil {
+		return false
+	}
+	u, err := url.ParseRequestURI(path)
+	return err == nil && u.Scheme != ""
+}
+
+// verifyDigest checks whether the SHA256 digest of the data matches the expected one.
+func verifyDigest(u string, data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("the SHA256 digest %s of the manifest %s does not match the expected digest %s",
+			actual, u, expected)
+	}
+	return nil
+}
+
+// manifestEntries returns the entries of spec.manifests and spec.additionalManifests, which
+// set any option on how to fetch them, keyed by their resolved URLs.
+func manifestEntries(instance v1alpha1.KComponent) map[string]v1alpha1.Manifest {
+	var manifests []v1alpha1.Manifest
+	for _, manifest := range instance.GetSpec().GetManifests() {
+		if hasFetchOptions(manifest) {
+			manifests = append(manifests, manifest)
+		}
+	}
+	for _, manifest := range instance.GetSpec().GetAdditionalManifests() {
+		if hasFetchOptions(manifest) {
+			manifests = append(manifests, manifest)
+		}
+	}
+	version := TargetVersion(instance)
+	entries := make(map[string]v1alpha1.Manifest, len(manifests))
+	for _, manifest := range manifests {
+		entries[manifestURL(instance, manifest, version)] = manifest
+	}
+	// The manifests of the ingresses are resolved with the versions of the ingresses.
+	if ks, ok := instance.(*v1alpha1.KnativeServing); ok {
+		for _, release := range ks.Spec.IngressReleases() {
+			for _, manifest := range release.Manifests {
+				if hasFetchOptions(manifest) {
+					entries[manifestURL(instance, manifest, IngressVersion(release, version))] = manifest
+				}
+			}
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries
+}
+
+// IngressVersion returns the version of the pinned release of an ingress, which defaults to the
+// version of Knative Serving.
+func IngressVersion(release v1alpha1.IngressRelease, servingVersion string) string {
+	if release.Version != "" {
+		return release.Version
+	}
+	return servingVersion
+}
+
+// ManifestPath returns the comma-separated URLs of the manifests, with the variables replaced for
+// the version.
+func ManifestPath(instance v1alpha1.KComponent, manifests []v1alpha1.Manifest, version string) string {
+	urls := make([]string, 0, len(manifests))
+	for _, manifest := range manifests {
+		urls = append(urls, manifestURL(instance, manifest, version))
+	}
+	return strings.Join(urls, COMMA)
+}
+
+func hasFetchOptions(manifest v1alpha1.Manifest) bool {
+	return manifest.Sha256 != "" || manifest.CredentialsSecret != nil || manifest.CABundleConfigMap != nil
+}
+
+// manifestURL returns the URL of the manifest with the variables replaced. The manifests
+// stored in ConfigMaps or Secrets are referred to by the URLs built from their references,
+// and the URLs of kustomizations are prefixed with KustomizeScheme.
+func manifestURL(instance v1alpha1.KComponent, manifest v1alpha1.Manifest, version string) string {
+	if u := sourceURL(instance, manifest); u != "" {
+		return u
+	}
+	u := manifestVariables(instance, version).Replace(manifest.Url)
+	if manifest.Kustomize {
+		return KustomizeScheme + u
+	}
+	return u
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	mf "github.com/manifestival/manifestival"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+)
+
+const additionalResource = "testdata/kodata/additional-manifests/additional-resource.yaml"
+
+func digestOf(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestFetchManifestURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata/kodata")))
+	defer server.Close()
+	remote := server.URL + "/additional-manifests/additional-resource.yaml"
+	digest := digestOf(t, additionalResource)
+	mismatch := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		name      string
+		url       string
+		digest    string
+		expectErr bool
+	}{{
+		name: "local file without digest",
+		url:  additionalResource,
+	}, {
+		name:   "local file with matching digest",
+		url:    additionalResource,
+		digest: digest,
+	}, {
+		name:      "local file with mismatching digest",
+		url:       additionalResource,
+		digest:    mismatch,
+		expectErr: true,
+	}, {
+		name:   "remote URL with matching digest",
+		url:    remote,
+		digest: digest,
+	}, {
+		name:      "remote URL with mismatching digest",
+		url:       remote,
+		digest:    mismatch,
+		expectErr: true,
+	}, {
+		name:      "missing remote URL with digest",
+		url:       server.URL + "/missing.yaml",
+		digest:    digest,
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := fetchManifestURL(context.TODO(), &v1alpha1.KnativeServing{}, test.url,
+				v1alpha1.Manifest{Url: test.url, Sha256: test.digest})
+			util.AssertEqual(t, err != nil, test.expectErr)
+			if !test.expectErr {
+				util.AssertEqual(t, util.DeepMatchWithPath(m, additionalResource), true)
+			}
+		})
+	}
+}
+
+func TestAppendAdditionalManifestsDigestMismatch(t *testing.T) {
+	koPath := "testdata/kodata"
+	os.Setenv(KoEnvKey, koPath)
+	defer os.Unsetenv(KoEnvKey)
+
+	instance := &v1alpha1.KnativeServing{
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Version: "0.16.1",
+				AdditionalManifests: []v1alpha1.Manifest{{
+					Url:    additionalResource,
+					Sha256: "0000000000000000000000000000000000000000000000000000000000000000",
+				}},
+			},
+		},
+	}
+	instance.Status.InitializeConditions()
+	manifest, _ := mf.ManifestFrom(mf.Slice{})
+	err := AppendAdditionalManifests(context.TODO(), &manifest, instance)
+	util.AssertEqual(t, err != nil, true)
+	util.AssertEqual(t, instance.Status.GetCondition(v1alpha1.InstallSucceeded).IsFalse(), true)
+	util.AssertEqual(t, instance.Status.GetCondition(v1alpha1.InstallSucceeded).Message, "Install failed with message: "+err.Error())
+}
+
+func TestFetchManifestURLWithCredentials(t *testing.T) {
+	data, err := ioutil.ReadFile(additionalResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if r.Header.Get("Authorization") != "Bearer secret-token" && !(ok && username == "user" && password == "pass") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	const namespace = "knative-serving"
+	labels := map[string]string{ManifestSourceLabel: "true"}
+	objects := []runtime.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "token", Namespace: namespace, Labels: labels},
+			Data:       map[string][]byte{"token": []byte("secret-token\n")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "basic", Namespace: namespace, Labels: labels},
+			Data:       map[string][]byte{"username": []byte("user"), "password": []byte("pass")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "wrong", Namespace: namespace, Labels: labels},
+			Data:       map[string][]byte{"token": []byte("wrong-token")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: namespace, Labels: labels},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled", Namespace: namespace},
+			Data:       map[string][]byte{"token": []byte("secret-token")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca-bundle", Namespace: namespace, Labels: labels},
+			Data:       map[string]string{"ca.crt": string(caBundle)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled-ca-bundle", Namespace: namespace},
+			Data:       map[string]string{"ca.crt": string(caBundle)},
+		},
+	}
+	ctx, _ := fakekubeclient.With(context.Background(), objects...)
+	instance := &v1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}}
+	ref := func(name string) *corev1.LocalObjectReference {
+		return &corev1.LocalObjectReference{Name: name}
+	}
+
+	tests := []struct {
+		name      string
+		entry     v1alpha1.Manifest
+		expectErr bool
+	}{{
+		name:  "bearer token",
+		entry: v1alpha1.Manifest{CredentialsSecret: ref("token"), CABundleConfigMap: ref("ca-bundle")},
+	}, {
+		name:  "basic authentication",
+		entry: v1alpha1.Manifest{CredentialsSecret: ref("basic"), CABundleConfigMap: ref("ca-bundle")},
+	}, {
+		name:      "wrong token",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("wrong"), CABundleConfigMap: ref("ca-bundle")},
+		expectErr: true,
+	}, {
+		name:      "secret without credentials",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("empty"), CABundleConfigMap: ref("ca-bundle")},
+		expectErr: true,
+	}, {
+		name:      "unlabelled secret",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("unlabelled"), CABundleConfigMap: ref("ca-bundle")},
+		expectErr: true,
+	}, {
+		name:      "missing secret",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("missing"), CABundleConfigMap: ref("ca-bundle")},
+		expectErr: true,
+	}, {
+		name:      "untrusted CA",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("token")},
+		expectErr: true,
+	}, {
+		name:      "unlabelled CA bundle",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("token"), CABundleConfigMap: ref("unlabelled-ca-bundle")},
+		expectErr: true,
+	}, {
+		name:      "missing CA bundle",
+		entry:     v1alpha1.Manifest{CredentialsSecret: ref("token"), CABundleConfigMap: ref("missing")},
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.entry.Url = server.URL
+			m, err := fetchManifestURL(ctx, instance, server.URL, test.entry)
+			util.AssertEqual(t, err != nil, test.expectErr)
+			if !test.expectErr {
+				util.AssertEqual(t, util.DeepMatchWithPath(m, additionalResource), true)
+			}
+		})
+	}
+}
+
+func TestFetchManifestURLRetries(t *testing.T) {
+	defer func(backoff wait.Backoff) { fetchBackoff = backoff }(fetchBackoff)
+	fetchBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+
+	data, err := ioutil.ReadFile(additionalResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	m, err := fetchManifestURL(context.TODO(), &v1alpha1.KnativeServing{}, server.URL, v1alpha1.Manifest{})
+	util.AssertEqual(t, err, nil)
+	util.AssertEqual(t, attempts, 3)
+	util.AssertEqual(t, util.DeepMatchWithPath(m, additionalResource), true)
+
+	attempts = -10
+	_, err = fetchManifestURL(context.TODO(), &v1alpha1.KnativeServing{}, server.URL, v1alpha1.Manifest{})
+	util.AssertEqual(t, err.Error(), "failed to fetch the manifest "+server.URL+": 503 Service Unavailable")
+}
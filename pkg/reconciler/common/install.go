@@ -19,9 +19,9 @@ package common
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"knative.dev/pkg/logging"
 )
@@ -30,7 +30,6 @@ var (
 	role            mf.Predicate = mf.Any(mf.ByKind("ClusterRole"), mf.ByKind("Role"))
 	rolebinding     mf.Predicate = mf.Any(mf.ByKind("ClusterRoleBinding"), mf.ByKind("RoleBinding"))
 	webhook         mf.Predicate = mf.Any(mf.ByKind("MutatingWebhookConfiguration"), mf.ByKind("ValidatingWebhookConfiguration"))
-	gatewayNotMatch              = "no matches for kind \"Gateway\""
 )
 
 // Install applies the manifest resources for the given version and updates the given
@@ -52,13 +51,6 @@ func Install(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComp
 	}
 	if err := manifest.Filter(mf.Not(mf.Any(role, rolebinding, webhook))).Apply(); err != nil {
 		status.MarkInstallFailed(err.Error())
-		if ks, ok := instance.(*v1alpha1.KnativeServing); ok && strings.Contains(err.Error(), gatewayNotMatch) &&
-			(ks.Spec.Ingress == nil || ks.Spec.Ingress.Istio.Enabled) {
-			errMessage := fmt.Errorf("please install istio or disable the istio ingress plugin: %w", err)
-			status.MarkInstallFailed(errMessage.Error())
-			return errMessage
-		}
-
 		return fmt.Errorf("failed to apply non rbac manifest: %w", err)
 	}
 	if err := manifest.Filter(webhook).Apply(); err != nil {
@@ -68,9 +60,46 @@ func Install(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComp
 	status.MarkInstallSucceeded()
 	status.SetVersion(TargetVersion(instance))
 	status.SetManifests(targetManifestPathArray(instance))
+	recordEnabledPlugins(instance)
 	return nil
 }
 
+// recordEnabledPlugins records the ingresses and their pinned releases, the namespaces of the kourier
+// gateway and of the knative-local-gateway Service, the automatic TLS and the magic DNS, or the
+// sources enabled for the installed release in the status, so that the resources of the disabled
+// ones, of the previously pinned releases, or in the previous gateway namespaces, can be deleted later.
+func recordEnabledPlugins(instance v1alpha1.KComponent) {
+	switch instance := instance.(type) {
+	case *v1alpha1.KnativeServing:
+		instance.Status.Ingresses = instance.Spec.EnabledIngresses()
+		instance.Status.IngressReleases = instance.Spec.IngressReleases()
+		instance.Status.TLS = instance.Spec.TLSEnabled()
+		instance.Status.MagicDNS = instance.Spec.MagicDNSEnabled()
+		instance.Status.KourierGatewayNamespace = instance.Spec.KourierGatewayNamespace()
+		instance.Status.LocalGatewayNamespace = instance.Spec.LocalGatewayNamespace()
+	case *v1alpha1.KnativeEventing:
+		instance.Status.Sources = instance.Spec.EnabledSources()
+	}
+}
+
+// enabledPluginsChanged returns whether the ingresses and their pinned releases, the namespaces of the
+// kourier gateway and of the knative-local-gateway Service, the automatic TLS and the magic DNS, or
+// the sources enabled in the spec differ from the ones recorded in the status.
+func enabledPluginsChanged(instance v1alpha1.KComponent) bool {
+	switch instance := instance.(type) {
+	case *v1alpha1.KnativeServing:
+		return !equality.Semantic.DeepEqual(instance.Status.Ingresses, instance.Spec.EnabledIngresses()) ||
+			!equality.Semantic.DeepEqual(instance.Status.IngressReleases, instance.Spec.IngressReleases()) ||
+			instance.Status.TLS != instance.Spec.TLSEnabled() ||
+			instance.Status.MagicDNS != instance.Spec.MagicDNSEnabled() ||
+			instance.Status.KourierGatewayNamespace != instance.Spec.KourierGatewayNamespace() ||
+			instance.Status.LocalGatewayNamespace != instance.Spec.LocalGatewayNamespace()
+	case *v1alpha1.KnativeEventing:
+		return !equality.Semantic.DeepEqual(instance.Status.Sources, instance.Spec.EnabledSources())
+	}
+	return false
+}
+
 // Uninstall removes all resources except CRDs, which are never deleted automatically.
 func Uninstall(manifest *mf.Manifest) error {
 	if err := manifest.Filter(mf.NoCRDs, mf.Not(mf.Any(role, rolebinding))).Delete(); err != nil {
@@ -71,9 +71,13 @@ func TestInstall(t *testing.T) {
 			CommonSpec: v1alpha1.CommonSpec{
 				Version: version,
 			},
+			Source: &v1alpha1.SourceConfigs{
+				Kafka: v1alpha1.KafkaSourceConfiguration{Enabled: true},
+			},
 		},
 		Status: v1alpha1.KnativeEventingStatus{
 			Version: "0.13-test",
+			Sources: []string{"github"},
 		},
 	}
 	if err := Install(context.TODO(), &manifest, instance); err != nil {
@@ -92,6 +96,10 @@ func TestInstall(t *testing.T) {
 	if got, want := instance.GetStatus().GetVersion(), version; got != want {
 		t.Fatalf("GetVersion() = %s, want %s", got, want)
 	}
+
+	if got, want := instance.Status.Sources, []string{"kafka"}; !cmp.Equal(got, want) {
+		t.Fatalf("Status.Sources = %v, want %v", got, want)
+	}
 }
 
 func TestInstallError(t *testing.T) {
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/kustomize/api/filesys"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/yaml"
+)
+
+// KustomizeScheme is the prefix of the manifest URLs, which refer to kustomizations.
+const KustomizeScheme = "kustomize://"
+
+const (
+	// kustomizeOverlayDir is the directory of the in-memory file system, to which the
+	// kustomization is copied before it is built.
+	kustomizeOverlayDir = "/overlay"
+	// kustomizeBaseDir is the directory of the in-memory file system, which contains the
+	// manifests of the target version in the catalog. The kustomization can use it as the
+	// base ../base.
+	kustomizeBaseDir = "/base"
+)
+
+// isKustomize checks whether the URL refers to a kustomization.
+func isKustomize(u string) bool {
+	return strings.HasPrefix(u, KustomizeScheme)
+}
+
+// readKustomize builds the kustomization referenced by the URL, which is either a local
+// directory, or a local or remote tar archive, optionally gzipped. The kustomization.yaml
+// has to be located at the root of the directory or the archive. If the entry specifies a
+// digest, the archive has to match it.
+func readKustomize(ctx context.Context, instance v1alpha1.KComponent, u string, entry v1alpha1.Manifest) ([]byte, error) {
+	src := strings.TrimPrefix(u, KustomizeScheme)
+	fs := filesys.MakeFsInMemory()
+	if err := copyKustomizeBase(ctx, fs, instance); err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(src); err == nil && info.IsDir() {
+		if entry.Sha256 != "" {
+			return nil, fmt.Errorf("the SHA256 digest cannot be verified for the kustomization directory %s", src)
+		}
+		if err := copyDir(fs, src, kustomizeOverlayDir); err != nil {
+			return nil, fmt.Errorf("failed to read the kustomization %s: %w", src, err)
+		}
+	} else {
+		data, err := readURL(ctx, instance, src, entry)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Sha256 != "" {
+			if err := verifyDigest(src, data, entry.Sha256); err != nil {
+				return nil, err
+			}
+		}
+		if err := extractArchive(fs, data, kustomizeOverlayDir); err != nil {
+			return nil, fmt.Errorf("failed to extract the kustomization %s: %w", src, err)
+		}
+	}
+
+	resources, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, kustomizeOverlayDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the kustomization %s: %w", src, err)
+	}
+	return resources.AsYaml()
+}
+
+// copyKustomizeBase copies the manifests of the target version in the catalog to the base
+// directory, along with a kustomization.yaml listing them. Nothing is copied if the catalog
+// does not have the target version.
+func copyKustomizeBase(ctx context.Context, fs filesys.FileSystem, instance v1alpha1.KComponent) error {
+	basePath, err := CatalogManifests(componentCatalog(instance), TargetVersion(instance), "")
+	if err != nil {
+		return nil
+	}
+	base, err := fetchManifest(ctx, instance, basePath)
+	if err != nil {
+		return err
+	}
+
+	docs := make([][]byte, 0, len(base.Resources()))
+	for _, u := range base.Resources() {
+		doc, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return err
+		}
+		docs = append(docs, doc)
+	}
+	if err := fs.WriteFile(path.Join(kustomizeBaseDir, "base.yaml"), bytes.Join(docs, []byte("---\n"))); err != nil {
+		return err
+	}
+	kustomization, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  []string{"base.yaml"},
+	})
+	if err != nil {
+		return err
+	}
+	return fs.WriteFile(path.Join(kustomizeBaseDir, "kustomization.yaml"), kustomization)
+}
+
+// copyDir copies the regular files in the local directory to the target directory.
+func copyDir(fs filesys.FileSystem, src, target string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return fs.WriteFile(path.Join(target, filepath.ToSlash(rel)), data)
+	})
+}
+
+// extractArchive extracts the regular files in the tar archive, optionally gzipped, to the
+// target directory.
+func extractArchive(fs filesys.FileSystem, data []byte, target string) error {
+	r := bufio.NewReader(bytes.NewReader(data))
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = bufio.NewReader(gz)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean("/" + hdr.Name)
+		content, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := fs.WriteFile(path.Join(target, name), content); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+const kustomizeOverlay = "testdata/kustomize/overlay"
+
+// archiveOf returns the gzipped tarball of the files in the directory.
+func archiveOf(t *testing.T, dir string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: file.Name(), Mode: 0644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func resourceNames(m mf.Manifest) []string {
+	var names []string
+	for _, u := range m.Resources() {
+		names = append(names, u.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestFetchManifestKustomize(t *testing.T) {
+	koPath := "testdata/kodata"
+	os.Setenv(KoEnvKey, koPath)
+	defer os.Unsetenv(KoEnvKey)
+
+	archive := archiveOf(t, kustomizeOverlay)
+	sum := sha256.Sum256(archive)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/overlay.tar.gz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(archive)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		manifest  v1alpha1.Manifest
+		expectErr bool
+	}{{
+		name:     "local directory",
+		manifest: v1alpha1.Manifest{Url: kustomizeOverlay, Kustomize: true},
+	}, {
+		name:     "remote archive",
+		manifest: v1alpha1.Manifest{Url: server.URL + "/overlay.tar.gz", Kustomize: true},
+	}, {
+		name: "remote archive with matching digest",
+		manifest: v1alpha1.Manifest{Url: server.URL + "/overlay.tar.gz", Kustomize: true,
+			Sha256: hex.EncodeToString(sum[:])},
+	}, {
+		name: "remote archive with mismatching digest",
+		manifest: v1alpha1.Manifest{Url: server.URL + "/overlay.tar.gz", Kustomize: true,
+			Sha256: "0000000000000000000000000000000000000000000000000000000000000000"},
+		expectErr: true,
+	}, {
+		name: "local directory with digest",
+		manifest: v1alpha1.Manifest{Url: kustomizeOverlay, Kustomize: true,
+			Sha256: "0000000000000000000000000000000000000000000000000000000000000000"},
+		expectErr: true,
+	}, {
+		name:      "directory without kustomization",
+		manifest:  v1alpha1.Manifest{Url: "testdata/kodata/additional-manifests", Kustomize: true},
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instance := &v1alpha1.KnativeServing{
+				Spec: v1alpha1.KnativeServingSpec{
+					CommonSpec: v1alpha1.CommonSpec{
+						Version: "0.16.1",
+					},
+				},
+			}
+			u := manifestURL(instance, test.manifest, "0.16.1")
+			util.AssertEqual(t, u, KustomizeScheme+test.manifest.Url)
+			m, err := fetchManifestURL(context.TODO(), instance, u, test.manifest)
+			util.AssertEqual(t, err != nil, test.expectErr)
+			if test.expectErr {
+				return
+			}
+			util.AssertDeepEqual(t, resourceNames(m), []string{"knative-serving-core", "knative-serving-crd",
+				"knative-serving-extra", "knative-serving-hpa"})
+			for _, u := range m.Resources() {
+				util.AssertEqual(t, u.GetAnnotations()["example.com/team"], "platform")
+			}
+		})
+	}
+}
+
+func TestTargetManifestKustomize(t *testing.T) {
+	koPath := "testdata/kodata"
+	os.Setenv(KoEnvKey, koPath)
+	defer os.Unsetenv(KoEnvKey)
+
+	instance := &v1alpha1.KnativeServing{
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Version:   "0.16.1",
+				Manifests: []v1alpha1.Manifest{{Url: kustomizeOverlay, Kustomize: true}},
+			},
+		},
+	}
+	m, err := TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err, nil)
+	util.AssertEqual(t, len(m.Resources()), 4)
+
+	// The version labels of the built manifest have to match the target version.
+	instance.Spec.Version = "0.15.0"
+	_, err = TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err != nil, true)
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+)
+
+// OCIScheme is the prefix of the manifest URLs, which refer to OCI artifacts.
+const OCIScheme = "oci://"
+
+// yamlSeparator separates the YAML documents extracted from the layers of an artifact.
+var yamlSeparator = []byte("\n---\n")
+
+// isOCI checks whether the URL refers to an OCI artifact.
+func isOCI(u string) bool {
+	return strings.HasPrefix(u, OCIScheme)
+}
+
+// readOCI pulls the OCI artifact referenced by the URL, and returns the YAML extracted from
+// all of its layers. A layer is either a plain YAML document, or a tarball, optionally
+// gzipped, of YAML files. The credentials are taken from spec.registry.imagePullSecrets.
+// If the entry specifies a digest, the manifest of the artifact has to match it.
+func readOCI(ctx context.Context, instance v1alpha1.KComponent, u string, entry v1alpha1.Manifest) ([]byte, error) {
+	ref, err := name.ParseReference(strings.TrimPrefix(u, OCIScheme))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the OCI reference %s: %w", u, err)
+	}
+	keychain, err := pullSecretsKeychain(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(keychain), remote.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pull the OCI artifact %s: %w", u, err)
+	}
+	if entry.Sha256 != "" {
+		raw, err := img.RawManifest()
+		if err != nil {
+			return nil, err
+		}
+		if err := verifyDigest(u, raw, entry.Sha256); err != nil {
+			return nil, err
+		}
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, err
+	}
+	var docs [][]byte
+	for _, layer := range layers {
+		data, err := readLayer(layer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the layers of the OCI artifact %s: %w", u, err)
+		}
+		docs = append(docs, data...)
+	}
+	return bytes.Join(docs, yamlSeparator), nil
+}
+
+// readLayer returns the YAML documents in the layer.
+func readLayer(layer v1.Layer) ([][]byte, error) {
+	rc, err := layer.Compressed()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	if magic, err := r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = bufio.NewReader(gz)
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if !isTar(data) {
+		return [][]byte{data}, nil
+	}
+
+	var docs [][]byte
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		ext := filepath.Ext(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		doc, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+}
+
+// isTar checks whether the data starts with a tar header.
+func isTar(data []byte) bool {
+	// The magic of a POSIX (ustar) header is located at the offset 257.
+	return len(data) > 262 && bytes.HasPrefix(data[257:], []byte("ustar"))
+}
+
+// dockerConfigJSON is the content of a Secret of the type kubernetes.io/dockerconfigjson.
+type dockerConfigJSON struct {
+	Auths map[string]authn.AuthConfig `json:"auths"`
+}
+
+// secretsKeychain resolves the credentials of a registry from the image pull secrets.
+type secretsKeychain struct {
+	auths map[string]authn.AuthConfig
+}
+
+var _ authn.Keychain = (*secretsKeychain)(nil)
+
+// Resolve implements authn.Keychain.
+func (k *secretsKeychain) Resolve(target authn.Resource) (authn.Authenticator, error) {
+	registry := target.RegistryStr()
+	if auth, ok := k.auths[registry]; ok {
+		return authn.FromConfig(auth), nil
+	}
+	if registry == name.DefaultRegistry {
+		if auth, ok := k.auths["index.docker.io"]; ok {
+			return authn.FromConfig(auth), nil
+		}
+	}
+	return authn.Anonymous, nil
+}
+
+// pullSecretsKeychain returns the keychain built from spec.registry.imagePullSecrets.
+func pullSecretsKeychain(ctx context.Context, instance v1alpha1.KComponent) (authn.Keychain, error) {
+	keychain := &secretsKeychain{auths: map[string]authn.AuthConfig{}}
+	for _, ref := range instance.GetSpec().GetRegistry().ImagePullSecrets {
+		secret, err := kubeclient.Get(ctx).CoreV1().Secrets(instance.GetNamespace()).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the image pull secret %s: %w", ref.Name, err)
+		}
+		auths := map[string]authn.AuthConfig{}
+		if data, ok := secret.Data[corev1.DockerConfigJsonKey]; ok {
+			var config dockerConfigJSON
+			if err := json.Unmarshal(data, &config); err != nil {
+				return nil, fmt.Errorf("failed to parse the image pull secret %s: %w", ref.Name, err)
+			}
+			auths = config.Auths
+		} else if data, ok := secret.Data[corev1.DockerConfigKey]; ok {
+			if err := json.Unmarshal(data, &auths); err != nil {
+				return nil, fmt.Errorf("failed to parse the image pull secret %s: %w", ref.Name, err)
+			}
+		}
+		for registry, auth := range auths {
+			keychain.auths[registryHost(registry)] = auth
+		}
+	}
+	return keychain, nil
+}
+
+// registryHost returns the host of the registry key in a docker config, which may also
+// contain a scheme and a path, e.g. https://index.docker.io/v1/.
+func registryHost(registry string) string {
+	registry = strings.TrimPrefix(strings.TrimPrefix(registry, "https://"), "http://")
+	return strings.SplitN(registry, "/", 2)[0]
+}
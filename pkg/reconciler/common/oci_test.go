@@ -0,0 +1,255 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	stdlog "log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+)
+
+// yamlLayer is a layer with a plain YAML document as its content.
+type yamlLayer struct {
+	data []byte
+}
+
+func (l yamlLayer) Digest() (v1.Hash, error) {
+	sum := sha256.Sum256(l.data)
+	return v1.Hash{Algorithm: "sha256", Hex: hex.EncodeToString(sum[:])}, nil
+}
+func (l yamlLayer) DiffID() (v1.Hash, error) {
+	return l.Digest()
+}
+func (l yamlLayer) Compressed() (io.ReadCloser, error) {
+	return ioutil.NopCloser(bytes.NewReader(l.data)), nil
+}
+func (l yamlLayer) Uncompressed() (io.ReadCloser, error) {
+	return l.Compressed()
+}
+func (l yamlLayer) Size() (int64, error) {
+	return int64(len(l.data)), nil
+}
+func (l yamlLayer) MediaType() (types.MediaType, error) {
+	return "application/vnd.knative.manifest.v1+yaml", nil
+}
+
+// tarLayer returns a gzipped tarball layer with the files.
+func tarLayer(t *testing.T, files map[string][]byte) v1.Layer {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	layer, err := tarball.LayerFromReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return layer
+}
+
+func pushArtifact(t *testing.T, ref string, layers ...v1.Layer) v1.Image {
+	img, err := mutate.AppendLayers(empty.Image, layers...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag, err := name.ParseReference(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := remote.Write(tag, img, remote.WithAuth(&authn.Basic{Username: "user", Password: "pass"})); err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
+
+func TestFetchManifestOCI(t *testing.T) {
+	registryHandler := registry.New(registry.Logger(stdlog.New(ioutil.Discard, "", 0)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		registryHandler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := u.Host
+
+	core, err := ioutil.ReadFile(SERVING_CORE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hpa, err := ioutil.ReadFile(SERVING_HPA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushArtifact(t, host+"/knative/serving:plain", yamlLayer{core}, yamlLayer{hpa})
+	img := pushArtifact(t, host+"/knative/serving:tarball", tarLayer(t, map[string][]byte{
+		"serving-core.yaml": core,
+		"serving-hpa.yml":   hpa,
+		"README.md":         []byte("not a manifest"),
+	}))
+	digest, err := img.Digest()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dockerConfig := fmt.Sprintf(`{"auths":{"http://%s/v2/":{"username":"user","password":"pass"}}}`, host)
+	const namespace = "knative-serving"
+	ctx, _ := fakekubeclient.With(context.Background(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials", Namespace: namespace},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(dockerConfig)},
+	})
+	withSecret := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Registry: v1alpha1.Registry{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+				},
+			},
+		},
+	}
+	expected := SERVING_CORE + COMMA + SERVING_HPA
+
+	tests := []struct {
+		name      string
+		instance  v1alpha1.KComponent
+		url       string
+		sha256    string
+		expectErr bool
+	}{{
+		name:     "plain YAML layers by tag",
+		instance: withSecret,
+		url:      OCIScheme + host + "/knative/serving:plain",
+	}, {
+		name:     "tarball layer by tag",
+		instance: withSecret,
+		url:      OCIScheme + host + "/knative/serving:tarball",
+	}, {
+		name:     "tarball layer by digest",
+		instance: withSecret,
+		url:      OCIScheme + host + "/knative/serving@" + digest.String(),
+	}, {
+		name:     "matching manifest digest",
+		instance: withSecret,
+		url:      OCIScheme + host + "/knative/serving:tarball",
+		sha256:   digest.Hex,
+	}, {
+		name:      "mismatching manifest digest",
+		instance:  withSecret,
+		url:       OCIScheme + host + "/knative/serving:tarball",
+		sha256:    "0000000000000000000000000000000000000000000000000000000000000000",
+		expectErr: true,
+	}, {
+		name:      "missing credentials",
+		instance:  &v1alpha1.KnativeServing{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}},
+		url:       OCIScheme + host + "/knative/serving:tarball",
+		expectErr: true,
+	}, {
+		name:      "missing tag",
+		instance:  withSecret,
+		url:       OCIScheme + host + "/knative/serving:missing",
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, err := fetchManifestURL(ctx, test.instance, test.url, v1alpha1.Manifest{Url: test.url, Sha256: test.sha256})
+			util.AssertEqual(t, err != nil, test.expectErr)
+			if !test.expectErr {
+				util.AssertEqual(t, util.ResourceMatchWithPath(m, expected), true)
+			}
+		})
+	}
+}
+
+func TestTargetManifestOCI(t *testing.T) {
+	server := httptest.NewServer(registry.New(registry.Logger(stdlog.New(ioutil.Discard, "", 0))))
+	defer server.Close()
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	core, err := ioutil.ReadFile(SERVING_CORE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushArtifact(t, u.Host+"/knative/serving:0.16.1", yamlLayer{core})
+
+	koPath := "testdata/kodata"
+	os.Setenv(KoEnvKey, koPath)
+	defer os.Unsetenv(KoEnvKey)
+
+	manifestURL := OCIScheme + u.Host + "/knative/serving:" + VersionVariable
+	instance := &v1alpha1.KnativeServing{
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Version:   "0.16.1",
+				Manifests: []v1alpha1.Manifest{{Url: manifestURL}},
+			},
+		},
+	}
+	m, err := TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err, nil)
+	util.AssertEqual(t, util.ResourceMatchWithPath(m, SERVING_CORE), true)
+
+	// The version labels of the artifact have to match the target version.
+	instance.Spec.Version = "0.15.0"
+	pushArtifact(t, u.Host+"/knative/serving:0.15.0", yamlLayer{core})
+	_, err = TargetManifest(context.TODO(), instance)
+	util.AssertEqual(t, err != nil, true)
+}
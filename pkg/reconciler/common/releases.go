@@ -17,12 +17,8 @@ limitations under the License.
 package common
 
 import (
+	"context"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path"
-	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -36,14 +32,21 @@ const (
 	KoEnvKey = "KO_DATA_PATH"
 	// VersionVariable is a string, which can be replaced with the value of spec.version
 	VersionVariable = "${VERSION}"
+	// MajorMinorVariable is a string, which can be replaced with the major.minor of spec.version
+	MajorMinorVariable = "${MAJOR_MINOR}"
+	// ComponentVariable is a string, which can be replaced with the name of the component,
+	// i.e. serving or eventing
+	ComponentVariable = "${COMPONENT}"
+	// NamespaceVariable is a string, which can be replaced with the namespace of the component
+	NamespaceVariable = "${NAMESPACE}"
+	// IngressVariable is a string, which can be replaced with the name of the enabled ingress
+	IngressVariable = "${INGRESS}"
 	// COMMA is the character comma
 	COMMA = ","
 	// LATEST_VERSION is the special version Knative Operator support, besides all semantic versions of Knative.
 	LATEST_VERSION = "latest"
 )
 
-var cache = map[string]mf.Manifest{}
-
 // TargetVersion returns the version of the manifest to be installed
 // per the spec in the component. If spec.version is empty, the latest
 // version known to the operator is returned.
@@ -68,31 +71,31 @@ func TargetVersion(instance v1alpha1.KComponent) string {
 
 // TargetManifest returns the default manifest for the TargetVersion or the manifest for the TargetVersion specified
 // with spec.manifests
-func TargetManifest(instance v1alpha1.KComponent) (mf.Manifest, error) {
+func TargetManifest(ctx context.Context, instance v1alpha1.KComponent) (mf.Manifest, error) {
 	manifestsPath := targetManifestPath(instance)
 	if len(instance.GetSpec().GetManifests()) == 0 {
-		getManifestWithVersionValidation(manifestsPath, instance, FetchManifest)
+		return getManifestWithVersionValidation(ctx, manifestsPath, instance, FetchManifest)
 	}
-	return getManifestWithVersionValidation(manifestsPath, instance, fetchManifestFromPath)
+	return getManifestWithVersionValidation(ctx, manifestsPath, instance, FetchManifestFromPath)
 }
 
 // TargetAdditionalManifest returns the manifest for the TargetVersion specified with spec.additionalManifests.
-func TargetAdditionalManifest(instance v1alpha1.KComponent) (mf.Manifest, error) {
+func TargetAdditionalManifest(ctx context.Context, instance v1alpha1.KComponent) (mf.Manifest, error) {
 	additionalManifestsPath := additionalManifestPath(instance)
 	if additionalManifestsPath == "" {
 		return mf.Manifest{}, nil
 	}
-	return getManifestWithVersionValidation(additionalManifestsPath, instance, fetchManifestFromPath)
+	return getManifestWithVersionValidation(ctx, additionalManifestsPath, instance, FetchManifestFromPath)
 }
 
 // InstalledManifest returns the version currently installed, which is
 // harder than it sounds, since status.version isn't set until the
 // target version is successfully installed, which can take some time.
 // So we return the target manifest if status.version is empty.
-func InstalledManifest(instance v1alpha1.KComponent) (mf.Manifest, error) {
+func InstalledManifest(ctx context.Context, instance v1alpha1.KComponent) (mf.Manifest, error) {
 	current := instance.GetStatus().GetVersion()
 	if len(instance.GetStatus().GetManifests()) == 0 && current == "" {
-		return TargetManifest(instance)
+		return TargetManifest(ctx, instance)
 	}
 	// If status.manifests is not empty, get the manifests from the cache if available, and get them from
 	// the path if not available in the cache.
@@ -102,12 +105,12 @@ func InstalledManifest(instance v1alpha1.KComponent) (mf.Manifest, error) {
 	if len(paths) == 0 {
 		return mf.Manifest{}, nil
 	}
-	manifest, err := FetchManifest(paths[0])
+	manifest, err := FetchManifest(ctx, instance, paths[0])
 	if err != nil {
 		return manifest, err
 	}
 	for i := 1; i < len(paths); i++ {
-		m, er := FetchManifest(paths[i])
+		m, er := FetchManifest(ctx, instance, paths[i])
 		if er != nil {
 			return manifest, er
 		}
@@ -121,6 +124,9 @@ func InstalledManifest(instance v1alpha1.KComponent) (mf.Manifest, error) {
 func IsVersionValidMigrationEligible(instance v1alpha1.KComponent) error {
 	var err error
 	targetVersion := TargetVersion(instance)
+	if targetVersion == "" && len(instance.GetSpec().GetManifests()) == 0 {
+		return fmt.Errorf("no version of %s is available in the catalog", componentCatalog(instance))
+	}
 	if targetVersion == LATEST_VERSION {
 		return nil
 	}
@@ -179,11 +185,11 @@ func getVersionKey(instance v1alpha1.KComponent) string {
 	return ""
 }
 
-type manifestFetcher func(string) (mf.Manifest, error)
+type manifestFetcher func(context.Context, v1alpha1.KComponent, string) (mf.Manifest, error)
 
-func getManifestWithVersionValidation(manifestsPath string, instance v1alpha1.KComponent, fn manifestFetcher) (mf.Manifest, error) {
+func getManifestWithVersionValidation(ctx context.Context, manifestsPath string, instance v1alpha1.KComponent, fn manifestFetcher) (mf.Manifest, error) {
 	version := TargetVersion(instance)
-	manifests, err := fn(manifestsPath)
+	manifests, err := fn(ctx, instance, manifestsPath)
 	if err != nil {
 		if len(instance.GetSpec().GetManifests()) == 0 && len(instance.GetSpec().GetAdditionalManifests()) == 0 {
 			// If we cannot access the manifests, there is no need to check whether the versions match.
@@ -229,55 +235,49 @@ func abs(x int) int {
 }
 
 // FetchManifest returns the manifest by either getting it from the cache, or reading them from the path.
-// The manifest is saved in the cache, if it is not available.
-func FetchManifest(path string) (mf.Manifest, error) {
-	if m, ok := cache[path]; ok {
+// The manifest is saved in the cache of the component, if it is not available.
+func FetchManifest(ctx context.Context, instance v1alpha1.KComponent, path string) (mf.Manifest, error) {
+	key := cacheKey{component: cacheNamespace(instance), path: path}
+	if m, ok := cache.get(key); ok {
+		recordCacheLookup(instance, cacheHit)
 		return m, nil
 	}
-	result, err := mf.NewManifest(path)
+	recordCacheLookup(instance, cacheMiss)
+	result, err := fetchManifest(ctx, instance, path)
 	if err == nil {
-		cache[path] = result
+		cache.set(key, result)
 	}
 	return result, err
 }
 
-// fetchManifestFromPath returns the manifest by reading them from the path, and saves them in the cache.
-func fetchManifestFromPath(path string) (mf.Manifest, error) {
-	result, err := mf.NewManifest(path)
+// FetchManifestFromPath returns the manifest by reading them from the path, bypassing the cache so that
+// the changes to mutable URLs, ConfigMaps and Secrets are picked up, and saves them in the cache of the
+// component.
+func FetchManifestFromPath(ctx context.Context, instance v1alpha1.KComponent, path string) (mf.Manifest, error) {
+	result, err := fetchManifest(ctx, instance, path)
 	if err == nil {
-		cache[path] = result
+		cache.set(cacheKey{component: cacheNamespace(instance), path: path}, result)
 	}
 	return result, err
 }
 
-// ClearCache removes all the records saved in the cache.
-func ClearCache() {
-	cache = map[string]mf.Manifest{}
-}
-
-func componentDir(instance v1alpha1.KComponent) string {
-	koDataDir := os.Getenv(KoEnvKey)
+// componentCatalog returns the name of the catalog entries of the component.
+func componentCatalog(instance v1alpha1.KComponent) string {
 	switch instance.(type) {
 	case *v1alpha1.KnativeServing:
-		return filepath.Join(koDataDir, "knative-serving")
+		return "knative-serving"
 	case *v1alpha1.KnativeEventing:
-		return filepath.Join(koDataDir, "knative-eventing")
+		return "knative-eventing"
 	}
 	return ""
 }
 
-func componentIngressDir() string {
-	koDataDir := os.Getenv(KoEnvKey)
-	return filepath.Join(koDataDir, "ingress")
-}
-
 func additionalManifestPath(instance v1alpha1.KComponent) string {
 	// Create the comma-separated string for URLs in spec.additionalManifests
 	addManifests := instance.GetSpec().GetAdditionalManifests()
 	urls := make([]string, 0, len(addManifests))
 	for _, manifest := range addManifests {
-		url := strings.ReplaceAll(manifest.Url, VersionVariable, TargetVersion(instance))
-		urls = append(urls, url)
+		urls = append(urls, manifestURL(instance, manifest, TargetVersion(instance)))
 	}
 	return strings.Join(urls, COMMA)
 }
@@ -288,17 +288,13 @@ func targetManifestPath(instance v1alpha1.KComponent) string {
 	// Create the comma-separated string as the URL to retrieve the manifest
 	urls := make([]string, 0, len(manifests))
 	for _, manifest := range manifests {
-		url := strings.ReplaceAll(manifest.Url, VersionVariable, version)
-		urls = append(urls, url)
+		urls = append(urls, manifestURL(instance, manifest, version))
 	}
 
 	manifestPath := strings.Join(urls, COMMA)
-	// If spec.manifests is empty, add the local path
+	// If spec.manifests is empty, add the manifests in the catalog
 	if manifestPath == "" {
-		manifestPath = filepath.Join(componentDir(instance), version)
-		if _, err := os.Stat(manifestPath); os.IsNotExist(err) {
-			return ""
-		}
+		manifestPath, _ = CatalogManifests(componentCatalog(instance), version, "")
 	}
 	return manifestPath
 }
@@ -319,9 +315,8 @@ func installedManifestPath(version string, instance v1alpha1.KComponent) []strin
 		return manifests
 	}
 
-	localPath := filepath.Join(componentDir(instance), version)
-	if _, err := os.Stat(localPath); !os.IsNotExist(err) {
-		return []string{localPath}
+	if catalogPath, err := CatalogManifests(componentCatalog(instance), version, ""); err == nil {
+		return []string{catalogPath}
 	}
 	return []string{}
 }
@@ -333,82 +328,82 @@ func SanitizeSemver(version string) string {
 	return fmt.Sprintf("v%s", version)
 }
 
-// allIngressReleases returns the all the available release versions
-// available under kodata directory for Knative component.
-func allIngressReleases() ([]string, error) {
-	// List all the directories available under kodata
-	pathname := componentIngressDir()
-	return allReleasesUnderPath(pathname)
-}
-
 // allReleases returns the all the available release versions
-// available under kodata directory for Knative component.
+// available in the catalog for Knative component.
 func allReleases(instance v1alpha1.KComponent) ([]string, error) {
-	// List all the directories available under kodata
-	pathname := componentDir(instance)
-	return allReleasesUnderPath(pathname)
+	return CatalogReleases(componentCatalog(instance))
 }
 
-// allComponentReleases returns the all the available release versions
-// available under kodata directory for a certain path.
-func allReleasesUnderPath(pathname string) ([]string, error) {
-	fileList, err := ioutil.ReadDir(pathname)
-	if err != nil {
-		return nil, err
-	}
+// latestRelease returns the latest release tag available in the catalog for Knative component.
+func latestRelease(instance v1alpha1.KComponent) string {
+	return getLatestRelease(instance, "")
+}
 
-	releaseTags := make([]string, 0, len(fileList))
-	for _, file := range fileList {
-		name := path.Join(pathname, file.Name())
-		pathDirOrFile, err := os.Stat(name)
-		if err != nil {
-			return nil, err
+// AlternativeManifests returns the manifests in the catalog for the name, e.g. IngressCatalog, and
+// the sub-entry, which match the version of the Knative component. The entry of the same patch version
+// is preferred, then the one of the same major.minor version, and finally the latest patch version of
+// the same major.minor version.
+func AlternativeManifests(name, version, sub string) (string, error) {
+	vers, _ := CatalogReleases(name)
+	if strings.EqualFold(version, LATEST_VERSION) {
+		if len(vers) != 0 {
+			version = getLatestReleaseFromList(vers, version)
 		}
-		if pathDirOrFile.IsDir() {
-			releaseTags = append(releaseTags, file.Name())
+		return CatalogManifests(name, version, sub)
+	}
+
+	// The version may be given in the format of major.minor, which has no patch version.
+	if strings.Count(version, ".") > 1 {
+		if manifests, err := CatalogManifests(name, strings.TrimPrefix(version, "v"), sub); err == nil {
+			return manifests, nil
 		}
 	}
-	if len(releaseTags) == 0 {
-		return nil, fmt.Errorf("unable to find any version number under the path %v", pathname)
+	majorMinor := semver.MajorMinor(SanitizeSemver(strings.TrimPrefix(version, "v")))
+	manifests, err := CatalogManifests(name, strings.TrimPrefix(majorMinor, "v"), sub)
+	if err == nil {
+		return manifests, nil
+	}
+	for _, val := range vers {
+		if semver.MajorMinor(SanitizeSemver(val)) == majorMinor {
+			if manifests, verr := CatalogManifests(name, val, sub); verr == nil {
+				return manifests, nil
+			}
+		}
 	}
-
-	// This function makes sure the versions are sorted in a descending order.
-	sort.Slice(releaseTags, func(i, j int) bool {
-		// The index i is the one after the index j. If i is more recent than j, return true to swap.
-		return semver.Compare(SanitizeSemver(releaseTags[i]), SanitizeSemver(releaseTags[j])) == 1
-	})
-
-	return releaseTags, nil
-}
-
-// latestRelease returns the latest release tag available under kodata directory for Knative component.
-func latestRelease(instance v1alpha1.KComponent) string {
-	return getLatestRelease(instance, "")
+	return "", err
 }
 
-// GetLatestIngressRelease returns the latest release tag available under kodata directory for the ingress
-// based on spec.version.
-func GetLatestIngressRelease(version string) string {
-	// The versions are in a descending order, so the first one will be the latest version.
-	vers, err := allIngressReleases()
+// AppendAlternativeManifests appends the manifests in the catalog for the name, e.g. CertManagerCatalog,
+// which match the version of the Knative component. No manifest is appended if the version is empty.
+func AppendAlternativeManifests(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent, name, version string) error {
+	if version == "" {
+		return nil
+	}
+	path, err := AlternativeManifests(name, version, "")
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return getLatestReleaseFromList(vers, version)
+	m, err := FetchManifest(ctx, instance, path)
+	if err != nil {
+		return err
+	}
+	*manifest = manifest.Append(m)
+	return nil
 }
 
-// getLatestRelease returns the latest release tag available under kodata directory for Knative component
-// based on spec.version.
+// getLatestRelease returns the latest release tag available in the catalog for Knative component
+// based on spec.version. If the catalog has no version of the component, the version is returned
+// as is, so that looking up its manifests reports the missing entry.
 func getLatestRelease(instance v1alpha1.KComponent, version string) string {
 	// The versions are in a descending order, so the first one will be the latest version.
 	vers, err := allReleases(instance)
 	if err != nil {
-		panic(err)
+		return version
 	}
 	return getLatestReleaseFromList(vers, version)
 }
 
-// getLatestReleaseFromList returns the latest release tag available under kodata directory for Knative component
+// getLatestReleaseFromList returns the latest release tag available in the catalog for Knative component
 // based on spec.version.
 func getLatestReleaseFromList(vers []string, version string) string {
 	if version == "" {
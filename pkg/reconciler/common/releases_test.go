@@ -17,12 +17,14 @@ limitations under the License.
 package common
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 	"testing"
 
 	mf "github.com/manifestival/manifestival"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	util "knative.dev/operator/pkg/reconciler/common/testing"
 )
@@ -818,7 +820,7 @@ func TestTargetManifest(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			m, err := TargetManifest(test.component)
+			m, err := TargetManifest(context.TODO(), test.component)
 			if err != nil {
 				util.AssertEqual(t, err.Error(), test.expectedError.Error())
 				util.AssertEqual(t, len(m.Resources()), 0)
@@ -922,7 +924,7 @@ func TestTargetAdditionalManifest(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			m, err := TargetAdditionalManifest(test.component)
+			m, err := TargetAdditionalManifest(context.TODO(), test.component)
 			util.AssertEqual(t, err, nil)
 			if test.expectedManifestsPath != "" {
 				util.AssertEqual(t, util.DeepMatchWithPath(m, test.expectedManifestsPath), true)
@@ -1004,6 +1006,25 @@ func TestTargetManifestPathArray(t *testing.T) {
 			},
 		},
 		expectedManifestsPath: []string{os.Getenv(KoEnvKey) + "/knative-serving/0.16.1"},
+	}, {
+		name: "knative-serving with variables in spec.manifests and spec.additionalManifests",
+		component: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving"},
+			Spec: v1alpha1.KnativeServingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					Version:           "0.16.1",
+					ManifestVariables: map[string]string{"ARCH": "arm64"},
+					Manifests: []v1alpha1.Manifest{{
+						Url: "https://example.com/${COMPONENT}/${MAJOR_MINOR}/${ARCH}/core-${VERSION}.yaml",
+					}},
+					AdditionalManifests: []v1alpha1.Manifest{{
+						Url: "https://example.com/${NAMESPACE}/${INGRESS}.yaml",
+					}},
+				},
+			},
+		},
+		expectedManifestsPath: []string{"https://example.com/serving/0.16/arm64/core-0.16.1.yaml",
+			"https://example.com/knative-serving/istio.yaml"},
 	}}
 
 	for _, test := range tests {
@@ -1089,7 +1110,7 @@ func TestInstalledManifest(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			m, err := InstalledManifest(test.component)
+			m, err := InstalledManifest(context.TODO(), test.component)
 			// The InstalledManifest should never raise the error, even of the manifests are not available.
 			// If the installed manifests are unable to retrieve, it returns a manifest with no resource.
 			util.AssertEqual(t, util.DeepMatchWithPath(m, test.expectedManifestsPath), true)
@@ -1097,18 +1118,3 @@ func TestInstalledManifest(t *testing.T) {
 		})
 	}
 }
-
-func TestCache(t *testing.T) {
-	// Make sure to start with empty cache
-	ClearCache()
-	util.AssertEqual(t, len(cache), 0)
-	expectedPath := "testdata/kodata/knative-serving/0.16.1/"
-	manifest, err := mf.NewManifest(expectedPath)
-	cache["key"] = manifest
-	util.AssertEqual(t, len(cache), 1)
-	util.AssertEqual(t, err, nil)
-	m := cache["key"]
-	util.AssertEqual(t, util.DeepMatchWithPath(m, expectedPath), true)
-	ClearCache()
-	util.AssertEqual(t, len(cache), 0)
-}
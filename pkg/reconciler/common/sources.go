@@ -0,0 +1,148 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	kubeclient "knative.dev/pkg/client/injection/kube/client"
+	"knative.dev/pkg/tracker"
+)
+
+const (
+	// ConfigMapScheme is the prefix of the manifest URLs, which refer to the keys of ConfigMaps.
+	ConfigMapScheme = "configmap://"
+	// SecretScheme is the prefix of the manifest URLs, which refer to the keys of Secrets.
+	SecretScheme = "secret://"
+
+	// ManifestSourceLabel is the label, which the ConfigMaps and Secrets containing manifests
+	// have to set to true. The operator only reads and watches the labelled ones.
+	ManifestSourceLabel = "operator.knative.dev/manifest-source"
+	// ManifestSourceSelector selects the ConfigMaps and Secrets containing manifests.
+	ManifestSourceSelector = ManifestSourceLabel + "=true"
+)
+
+// sourceURL returns the URL of the ConfigMap or Secret key referenced by the manifest, in the
+// form of scheme://namespace/name/key, or an empty string if there is no such reference.
+func sourceURL(instance v1alpha1.KComponent, manifest v1alpha1.Manifest) string {
+	switch {
+	case manifest.ConfigMap != nil:
+		return ConfigMapScheme + sourcePath(instance, manifest.ConfigMap)
+	case manifest.Secret != nil:
+		return SecretScheme + sourcePath(instance, manifest.Secret)
+	}
+	return ""
+}
+
+func sourcePath(instance v1alpha1.KComponent, ref *v1alpha1.ManifestSourceReference) string {
+	return strings.Join([]string{instance.GetNamespace(), ref.Name, ref.Key}, "/")
+}
+
+// isSource checks whether the URL refers to the key of a ConfigMap or a Secret.
+func isSource(u string) bool {
+	return strings.HasPrefix(u, ConfigMapScheme) || strings.HasPrefix(u, SecretScheme)
+}
+
+// readSource returns the manifest saved under the key of the ConfigMap or Secret referenced
+// by the URL. Only the labelled ConfigMaps and Secrets in the namespace of the instance can be
+// read, so that the operator does not apply the content of any object in the cluster on behalf
+// of the users, who can only create the instance.
+func readSource(ctx context.Context, instance v1alpha1.KComponent, u string) ([]byte, error) {
+	scheme := ConfigMapScheme
+	if strings.HasPrefix(u, SecretScheme) {
+		scheme = SecretScheme
+	}
+	parts := strings.Split(strings.TrimPrefix(u, scheme), "/")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("the manifest URL %s should be in the form of %snamespace/name/key", u, scheme)
+	}
+	namespace, name, key := parts[0], parts[1], parts[2]
+	if namespace != instance.GetNamespace() {
+		return nil, fmt.Errorf("the manifest URL %s has to refer to the namespace %s", u, instance.GetNamespace())
+	}
+
+	client := kubeclient.Get(ctx).CoreV1()
+	if scheme == SecretScheme {
+		secret, err := client.Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the Secret %s/%s: %w", namespace, name, err)
+		}
+		if !isManifestSource(secret.ObjectMeta) {
+			return nil, fmt.Errorf("the Secret %s/%s is not labelled with %s", namespace, name, ManifestSourceSelector)
+		}
+		if data, ok := secret.Data[key]; ok {
+			return data, nil
+		}
+		return nil, fmt.Errorf("the key %s is not found in the Secret %s/%s", key, namespace, name)
+	}
+	cm, err := client.ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the ConfigMap %s/%s: %w", namespace, name, err)
+	}
+	if !isManifestSource(cm.ObjectMeta) {
+		return nil, fmt.Errorf("the ConfigMap %s/%s is not labelled with %s", namespace, name, ManifestSourceSelector)
+	}
+	if data, ok := cm.Data[key]; ok {
+		return []byte(data), nil
+	}
+	if data, ok := cm.BinaryData[key]; ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("the key %s is not found in the ConfigMap %s/%s", key, namespace, name)
+}
+
+// isManifestSource checks whether the object is labelled as containing manifests.
+func isManifestSource(meta metav1.ObjectMeta) bool {
+	return meta.Labels[ManifestSourceLabel] == "true"
+}
+
+// TrackManifestSources tracks the ConfigMaps and Secrets referenced in spec.manifests,
+// spec.additionalManifests and the manifests of the pinned releases of the ingresses, so that the
+// component is reconciled again when any of them changes.
+func TrackManifestSources(t tracker.Interface, instance v1alpha1.KComponent) error {
+	var manifests []v1alpha1.Manifest
+	manifests = append(manifests, instance.GetSpec().GetManifests()...)
+	manifests = append(manifests, instance.GetSpec().GetAdditionalManifests()...)
+	if ks, ok := instance.(*v1alpha1.KnativeServing); ok {
+		for _, release := range ks.Spec.IngressReleases() {
+			manifests = append(manifests, release.Manifests...)
+		}
+	}
+	for _, manifest := range manifests {
+		kind, ref := "ConfigMap", manifest.ConfigMap
+		if ref == nil {
+			kind, ref = "Secret", manifest.Secret
+		}
+		if ref == nil {
+			continue
+		}
+		if err := t.TrackReference(tracker.Reference{
+			APIVersion: corev1.SchemeGroupVersion.String(),
+			Kind:       kind,
+			Namespace:  instance.GetNamespace(),
+			Name:       ref.Name,
+		}, instance); err != nil {
+			return fmt.Errorf("failed to track the %s %s: %w", kind, ref.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	"knative.dev/pkg/tracker"
+)
+
+func TestFetchManifestSource(t *testing.T) {
+	data, err := ioutil.ReadFile(additionalResource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := map[string]string{ManifestSourceLabel: "true"}
+	ctx, _ := fakekubeclient.With(context.TODO(),
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "knative-serving", Labels: labels},
+			Data:       map[string]string{"resource.yaml": string(data)},
+			BinaryData: map[string][]byte{"binary.yaml": data},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled", Namespace: "knative-serving"},
+			Data:       map[string]string{"resource.yaml": string(data)},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "extensions", Labels: labels},
+			Data:       map[string]string{"resource.yaml": string(data)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "knative-serving", Labels: labels},
+			Data:       map[string][]byte{"resource.yaml": data},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabelled", Namespace: "knative-serving"},
+			Data:       map[string][]byte{"resource.yaml": data},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "extensions", Labels: labels},
+			Data:       map[string][]byte{"resource.yaml": data},
+		})
+
+	tests := []struct {
+		name      string
+		manifest  v1alpha1.Manifest
+		url       string
+		expectURL string
+		expectErr bool
+	}{{
+		name:      "ConfigMap in the namespace of the CR",
+		manifest:  v1alpha1.Manifest{ConfigMap: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "resource.yaml"}},
+		expectURL: "configmap://knative-serving/manifests/resource.yaml",
+	}, {
+		name:      "ConfigMap with binary data",
+		manifest:  v1alpha1.Manifest{ConfigMap: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "binary.yaml"}},
+		expectURL: "configmap://knative-serving/manifests/binary.yaml",
+	}, {
+		name:      "unlabelled ConfigMap",
+		manifest:  v1alpha1.Manifest{ConfigMap: &v1alpha1.ManifestSourceReference{Name: "unlabelled", Key: "resource.yaml"}},
+		expectURL: "configmap://knative-serving/unlabelled/resource.yaml",
+		expectErr: true,
+	}, {
+		name:      "ConfigMap in another namespace",
+		url:       "configmap://extensions/manifests/resource.yaml",
+		expectURL: "configmap://extensions/manifests/resource.yaml",
+		expectErr: true,
+	}, {
+		name:      "Secret",
+		manifest:  v1alpha1.Manifest{Secret: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "resource.yaml"}},
+		expectURL: "secret://knative-serving/manifests/resource.yaml",
+	}, {
+		name:      "unlabelled Secret",
+		manifest:  v1alpha1.Manifest{Secret: &v1alpha1.ManifestSourceReference{Name: "unlabelled", Key: "resource.yaml"}},
+		expectURL: "secret://knative-serving/unlabelled/resource.yaml",
+		expectErr: true,
+	}, {
+		name:      "Secret in another namespace",
+		url:       "secret://extensions/manifests/resource.yaml",
+		expectURL: "secret://extensions/manifests/resource.yaml",
+		expectErr: true,
+	}, {
+		name: "Secret with matching digest",
+		manifest: v1alpha1.Manifest{Secret: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "resource.yaml"},
+			Sha256: digestOf(t, additionalResource)},
+		expectURL: "secret://knative-serving/manifests/resource.yaml",
+	}, {
+		name: "Secret with mismatching digest",
+		manifest: v1alpha1.Manifest{Secret: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "resource.yaml"},
+			Sha256: "0000000000000000000000000000000000000000000000000000000000000000"},
+		expectURL: "secret://knative-serving/manifests/resource.yaml",
+		expectErr: true,
+	}, {
+		name:      "missing key",
+		manifest:  v1alpha1.Manifest{ConfigMap: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "missing.yaml"}},
+		expectURL: "configmap://knative-serving/manifests/missing.yaml",
+		expectErr: true,
+	}, {
+		name:      "missing Secret",
+		manifest:  v1alpha1.Manifest{Secret: &v1alpha1.ManifestSourceReference{Name: "missing", Key: "resource.yaml"}},
+		expectURL: "secret://knative-serving/missing/resource.yaml",
+		expectErr: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instance := &v1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+			}
+			test.manifest.Url = test.url
+			u := manifestURL(instance, test.manifest, "")
+			util.AssertEqual(t, u, test.expectURL)
+			m, err := fetchManifestURL(ctx, instance, u, test.manifest)
+			util.AssertEqual(t, err != nil, test.expectErr)
+			if !test.expectErr {
+				util.AssertEqual(t, util.DeepMatchWithPath(m, additionalResource), true)
+			}
+		})
+	}
+}
+
+func TestTargetManifestSource(t *testing.T) {
+	koPath := "testdata/kodata"
+	os.Setenv(KoEnvKey, koPath)
+	defer os.Unsetenv(KoEnvKey)
+
+	core, err := ioutil.ReadFile(SERVING_CORE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, client := fakekubeclient.With(context.TODO(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "manifests",
+			Namespace: "knative-serving",
+			Labels:    map[string]string{ManifestSourceLabel: "true"},
+		},
+		Data: map[string]string{"serving-core.yaml": string(core)},
+	})
+	instance := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Version: "0.16.1",
+				Manifests: []v1alpha1.Manifest{{
+					ConfigMap: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "serving-core.yaml"},
+				}},
+			},
+		},
+	}
+	m, err := TargetManifest(ctx, instance)
+	util.AssertEqual(t, err, nil)
+	util.AssertEqual(t, util.ResourceMatchWithPath(m, SERVING_CORE), true)
+
+	// The version labels of the manifest have to match the target version.
+	instance.Spec.Version = "0.15.0"
+	_, err = TargetManifest(ctx, instance)
+	util.AssertEqual(t, err != nil, true)
+
+	// The changes to the content of the ConfigMap are picked up.
+	instance.Spec.Version = "0.16.1"
+	cm, err := client.CoreV1().ConfigMaps("knative-serving").Get(ctx, "manifests", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm.Data["serving-core.yaml"] = ""
+	if _, err := client.CoreV1().ConfigMaps("knative-serving").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = TargetManifest(ctx, instance)
+	util.AssertEqual(t, err != nil, true)
+}
+
+func TestTrackManifestSources(t *testing.T) {
+	var enqueued []types.NamespacedName
+	tr := tracker.New(func(key types.NamespacedName) {
+		enqueued = append(enqueued, key)
+	}, time.Minute)
+
+	instance := &v1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "knative-serving"},
+		Spec: v1alpha1.KnativeServingSpec{
+			CommonSpec: v1alpha1.CommonSpec{
+				Manifests: []v1alpha1.Manifest{{
+					Url: "https://example.com/serving-core.yaml",
+				}, {
+					ConfigMap: &v1alpha1.ManifestSourceReference{Name: "manifests", Key: "serving-core.yaml"},
+				}},
+				AdditionalManifests: []v1alpha1.Manifest{{
+					Secret: &v1alpha1.ManifestSourceReference{Name: "extensions", Key: "extra.yaml"},
+				}},
+			},
+			Ingress: &v1alpha1.IngressConfigs{
+				Kourier: v1alpha1.KourierIngressConfiguration{
+					Enabled: true,
+					IngressRelease: v1alpha1.IngressRelease{
+						Manifests: []v1alpha1.Manifest{{
+							ConfigMap: &v1alpha1.ManifestSourceReference{Name: "kourier", Key: "kourier.yaml"},
+						}},
+					},
+				},
+			},
+		},
+	}
+	util.AssertEqual(t, TrackManifestSources(tr, instance), nil)
+
+	key := types.NamespacedName{Namespace: "knative-serving", Name: "knative-serving"}
+	for _, obj := range []runtime.Object{
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "manifests", Namespace: "knative-serving"},
+		},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "extensions", Namespace: "knative-serving"},
+		},
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "kourier", Namespace: "knative-serving"},
+		},
+	} {
+		enqueued = nil
+		tr.OnChanged(obj)
+		util.AssertDeepEqual(t, enqueued, []types.NamespacedName{key})
+	}
+
+	// The objects, which are not referenced, do not enqueue the CR.
+	for _, obj := range []runtime.Object{
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "knative-serving"},
+		},
+		&corev1.Secret{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+			ObjectMeta: metav1.ObjectMeta{Name: "extensions", Namespace: "extensions"},
+		},
+	} {
+		enqueued = nil
+		tr.OnChanged(obj)
+		util.AssertEqual(t, len(enqueued), 0)
+	}
+}
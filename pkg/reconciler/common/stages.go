@@ -49,7 +49,7 @@ func NoOp(context.Context, *mf.Manifest, v1alpha1.KComponent) error {
 // AppendTarget mutates the passed manifest by appending one
 // appropriate for the passed KComponent
 func AppendTarget(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
-	m, err := TargetManifest(instance)
+	m, err := TargetManifest(ctx, instance)
 	if err != nil {
 		instance.GetStatus().MarkInstallFailed(err.Error())
 		return err
@@ -61,7 +61,7 @@ func AppendTarget(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.
 // AppendAdditionalManifests mutates the passed manifest by appending the manifests specified with the
 // field spec.additionalManifests.
 func AppendAdditionalManifests(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
-	m, err := TargetAdditionalManifest(instance)
+	m, err := TargetAdditionalManifest(ctx, instance)
 	if err != nil {
 		instance.GetStatus().MarkInstallFailed(err.Error())
 		return err
@@ -78,11 +78,11 @@ func AppendAdditionalManifests(ctx context.Context, manifest *mf.Manifest, insta
 // corresponding to status.version
 func AppendInstalled(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
 	logger := logging.FromContext(ctx)
-	m, err := InstalledManifest(instance)
+	m, err := InstalledManifest(ctx, instance)
 	if err != nil {
 		// TODO: return the oldest instead of the latest?
 		logger.Error("Unable to fetch installed manifest, trying target", err)
-		m, err = TargetManifest(instance)
+		m, err = TargetManifest(ctx, instance)
 	}
 	if err != nil {
 		return err
@@ -103,7 +103,8 @@ func DeleteObsoleteResources(ctx context.Context, instance v1alpha1.KComponent,
 	version := TargetVersion(instance)
 	if version == instance.GetStatus().GetVersion() && len(instance.GetSpec().GetAdditionalManifests()) == 0 &&
 		len(instance.GetSpec().GetManifests()) == 0 &&
-		targetManifestPath(instance) == strings.Join(installedManifestPath(version, instance), COMMA) {
+		targetManifestPath(instance) == strings.Join(installedManifestPath(version, instance), COMMA) &&
+		!enabledPluginsChanged(instance) {
 		return NoOp
 	}
 	logger := logging.FromContext(ctx)
@@ -261,3 +261,136 @@ func TestDeleteObsoleteResources(t *testing.T) {
 		}
 	}
 }
+
+func TestDeleteObsoleteResourcesEnabledPlugins(t *testing.T) {
+	os.Setenv(KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(KoEnvKey)
+	version := "0.16.1"
+
+	tests := []struct {
+		name                  string
+		ingresses             []string
+		releases              map[string]v1alpha1.IngressRelease
+		gatewayNamespace      string
+		localGatewayNamespace string
+		spec                  *v1alpha1.IngressConfigs
+		tls                   *v1alpha1.TLSConfiguration
+		domain                *v1alpha1.DomainConfiguration
+		expected              bool
+	}{{
+		name:      "Unchanged ingresses",
+		ingresses: []string{"istio"},
+		expected:  false,
+	}, {
+		name:      "Changed ingresses",
+		ingresses: []string{"istio"},
+		spec: &v1alpha1.IngressConfigs{
+			Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true},
+		},
+		expected: true,
+	}, {
+		name:      "Enabled TLS",
+		ingresses: []string{"istio"},
+		tls:       &v1alpha1.TLSConfiguration{Enabled: true},
+		expected:  true,
+	}, {
+		name:      "Enabled magic DNS",
+		ingresses: []string{"istio"},
+		domain:    &v1alpha1.DomainConfiguration{MagicDNS: true},
+		expected:  true,
+	}, {
+		name:      "Unchanged pinned release",
+		ingresses: []string{"istio"},
+		releases:  map[string]v1alpha1.IngressRelease{"istio": {Version: "0.16"}},
+		spec: &v1alpha1.IngressConfigs{
+			Istio: v1alpha1.IstioIngressConfiguration{Enabled: true, IngressRelease: v1alpha1.IngressRelease{Version: "0.16"}},
+		},
+		expected: false,
+	}, {
+		name:      "Changed pinned release",
+		ingresses: []string{"istio"},
+		releases:  map[string]v1alpha1.IngressRelease{"istio": {Version: "0.16"}},
+		spec: &v1alpha1.IngressConfigs{
+			Istio: v1alpha1.IstioIngressConfiguration{Enabled: true, IngressRelease: v1alpha1.IngressRelease{Version: "0.15"}},
+		},
+		expected: true,
+	}, {
+		name:      "Removed pinned release",
+		ingresses: []string{"istio"},
+		releases:  map[string]v1alpha1.IngressRelease{"istio": {Version: "0.16"}},
+		spec: &v1alpha1.IngressConfigs{
+			Istio: v1alpha1.IstioIngressConfiguration{Enabled: true},
+		},
+		expected: true,
+	}, {
+		name:             "Unchanged kourier gateway namespace",
+		ingresses:        []string{"kourier"},
+		gatewayNamespace: "kourier-gateways",
+		spec: &v1alpha1.IngressConfigs{
+			Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true, GatewayNamespace: "kourier-gateways"},
+		},
+		expected: false,
+	}, {
+		name:             "Changed kourier gateway namespace",
+		ingresses:        []string{"kourier"},
+		gatewayNamespace: "kourier-gateways",
+		spec: &v1alpha1.IngressConfigs{
+			Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true, GatewayNamespace: "kourier-system"},
+		},
+		expected: true,
+	}, {
+		name:             "Removed kourier gateway namespace",
+		ingresses:        []string{"kourier"},
+		gatewayNamespace: "kourier-gateways",
+		spec: &v1alpha1.IngressConfigs{
+			Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true},
+		},
+		expected: true,
+	}, {
+		name:                  "Unchanged local gateway namespace",
+		ingresses:             []string{"istio"},
+		localGatewayNamespace: "istio-gateways",
+		spec: &v1alpha1.IngressConfigs{
+			Istio: v1alpha1.IstioIngressConfiguration{Enabled: true, LocalGatewayNamespace: "istio-gateways"},
+		},
+		expected: false,
+	}, {
+		name:                  "Changed local gateway namespace",
+		ingresses:             []string{"istio"},
+		localGatewayNamespace: "istio-gateways",
+		spec: &v1alpha1.IngressConfigs{
+			Istio: v1alpha1.IstioIngressConfiguration{Enabled: true, LocalGatewayNamespace: "istio-1-10"},
+		},
+		expected: true,
+	}, {
+		name:     "Unrecorded ingresses",
+		expected: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instance := &v1alpha1.KnativeServing{
+				Spec: v1alpha1.KnativeServingSpec{
+					CommonSpec: v1alpha1.CommonSpec{Version: version},
+					Ingress:    test.spec,
+					TLS:        test.tls,
+					Domain:     test.domain,
+				},
+				Status: v1alpha1.KnativeServingStatus{
+					Version:                 version,
+					Ingresses:               test.ingresses,
+					IngressReleases:         test.releases,
+					KourierGatewayNamespace: test.gatewayNamespace,
+					LocalGatewayNamespace:   test.localGatewayNamespace,
+				},
+			}
+			fetched := false
+			DeleteObsoleteResources(context.TODO(), instance,
+				func(context.Context, v1alpha1.KComponent) (*mf.Manifest, error) {
+					fetched = true
+					return &mf.Manifest{}, nil
+				})
+			util.AssertEqual(t, fetched, test.expected)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+)
+
+// manifestVariables returns the replacer of the variables in the manifest URLs. The built-in
+// variables take precedence over the user variables in spec.manifestVariables.
+func manifestVariables(instance v1alpha1.KComponent, version string) *strings.Replacer {
+	majorMinor := version
+	if mm := semver.MajorMinor(SanitizeSemver(version)); mm != "" {
+		majorMinor = mm[1:]
+	}
+	oldnew := []string{
+		VersionVariable, version,
+		MajorMinorVariable, majorMinor,
+		ComponentVariable, componentName(instance),
+		NamespaceVariable, instance.GetNamespace(),
+		IngressVariable, ingressName(instance),
+	}
+
+	variables := instance.GetSpec().GetManifestVariables()
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldnew = append(oldnew, "${"+name+"}", variables[name])
+	}
+	return strings.NewReplacer(oldnew...)
+}
+
+// componentName returns the name of the component, as used in the names of the Knative repositories.
+func componentName(instance v1alpha1.KComponent) string {
+	switch instance.(type) {
+	case *v1alpha1.KnativeServing:
+		return "serving"
+	case *v1alpha1.KnativeEventing:
+		return "eventing"
+	}
+	return ""
+}
+
+// ingressName returns the name of the ingress enabled for Knative Serving. If several ingresses
+// are enabled, spec.ingress.default or the first of istio, kourier, contour and gateway-api is
+// returned.
+func ingressName(instance v1alpha1.KComponent) string {
+	ks, ok := instance.(*v1alpha1.KnativeServing)
+	if !ok {
+		return ""
+	}
+	ingress := ks.Spec.Ingress
+	if ingress != nil && ingress.Default == "gatewayAPI" {
+		return "gateway-api"
+	}
+	if ingress != nil && ingress.Default != "" {
+		return ingress.Default
+	}
+	switch {
+	case ingress == nil || ingress.Istio.Enabled:
+		return "istio"
+	case ingress.Kourier.Enabled:
+		return "kourier"
+	case ingress.Contour.Enabled:
+		return "contour"
+	case ingress.GatewayAPI.Enabled:
+		return "gateway-api"
+	}
+	return ""
+}
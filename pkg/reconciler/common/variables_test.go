@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestManifestVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		component v1alpha1.KComponent
+		version   string
+		url       string
+		expected  string
+	}{{
+		name: "serving with the default ingress",
+		component: &v1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "knative-serving"},
+		},
+		version:  "0.24.0",
+		url:      "https://example.com/${COMPONENT}/v${MAJOR_MINOR}/${NAMESPACE}/${INGRESS}-${VERSION}.yaml",
+		expected: "https://example.com/serving/v0.24/knative-serving/istio-0.24.0.yaml",
+	}, {
+		name: "serving with kourier",
+		component: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					Kourier: v1alpha1.KourierIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+		version:  "0.24.0",
+		url:      "https://example.com/${INGRESS}.yaml",
+		expected: "https://example.com/kourier.yaml",
+	}, {
+		name: "serving with the Gateway API",
+		component: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					GatewayAPI: v1alpha1.GatewayAPIIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+		version:  "0.24.0",
+		url:      "https://example.com/net-${INGRESS}.yaml",
+		expected: "https://example.com/net-gateway-api.yaml",
+	}, {
+		name: "serving with the default of several ingresses",
+		component: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{
+					Istio:   v1alpha1.IstioIngressConfiguration{Enabled: true},
+					Contour: v1alpha1.ContourIngressConfiguration{Enabled: true},
+					Default: "contour",
+				},
+			},
+		},
+		version:  "0.24.0",
+		url:      "https://example.com/${INGRESS}.yaml",
+		expected: "https://example.com/contour.yaml",
+	}, {
+		name: "serving with no ingress enabled",
+		component: &v1alpha1.KnativeServing{
+			Spec: v1alpha1.KnativeServingSpec{
+				Ingress: &v1alpha1.IngressConfigs{},
+			},
+		},
+		version:  "0.24.0",
+		url:      "https://example.com/ingress-${INGRESS}.yaml",
+		expected: "https://example.com/ingress-.yaml",
+	}, {
+		name: "eventing with user variables",
+		component: &v1alpha1.KnativeEventing{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "knative-eventing"},
+			Spec: v1alpha1.KnativeEventingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					ManifestVariables: map[string]string{"ARCH": "arm64", "MIRROR": "mirror.example.com"},
+				},
+			},
+		},
+		version:  "0.24.1",
+		url:      "https://${MIRROR}/${COMPONENT}/${MAJOR_MINOR}/${ARCH}/eventing${INGRESS}.yaml",
+		expected: "https://mirror.example.com/eventing/0.24/arm64/eventing.yaml",
+	}, {
+		name: "built-in variables take precedence over user variables",
+		component: &v1alpha1.KnativeEventing{
+			Spec: v1alpha1.KnativeEventingSpec{
+				CommonSpec: v1alpha1.CommonSpec{
+					ManifestVariables: map[string]string{"VERSION": "0.1.0"},
+				},
+			},
+		},
+		version:  "0.24.1",
+		url:      "https://example.com/${VERSION}.yaml",
+		expected: "https://example.com/0.24.1.yaml",
+	}, {
+		name:      "latest version",
+		component: &v1alpha1.KnativeServing{},
+		version:   "latest",
+		url:       "https://example.com/${MAJOR_MINOR}/${VERSION}.yaml",
+		expected:  "https://example.com/latest/latest.yaml",
+	}, {
+		name:      "unknown variables are kept",
+		component: &v1alpha1.KnativeServing{},
+		version:   "0.24.0",
+		url:       "https://example.com/${UNKNOWN}.yaml",
+		expected:  "https://example.com/${UNKNOWN}.yaml",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			util.AssertEqual(t, manifestURL(test.component, v1alpha1.Manifest{Url: test.url}, test.version), test.expected)
+		})
+	}
+}
@@ -20,6 +20,7 @@ import (
 	mfc "github.com/manifestival/client-go-client"
 	mf "github.com/manifestival/manifestival"
 	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
@@ -29,10 +30,13 @@ import (
 	"knative.dev/operator/pkg/reconciler/common"
 	kubeclient "knative.dev/pkg/client/injection/kube/client"
 	deploymentinformer "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment"
+	configmapinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/filtered"
+	secretinformer "knative.dev/pkg/client/injection/kube/informers/core/v1/secret/filtered"
 	"knative.dev/pkg/configmap"
 	"knative.dev/pkg/controller"
 	"knative.dev/pkg/injection"
 	"knative.dev/pkg/logging"
+	"knative.dev/pkg/tracker"
 )
 
 // NewController initializes the controller and is called by the generated code
@@ -46,6 +50,8 @@ func NewExtendedController(generator common.ExtensionGenerator) injection.Contro
 	return func(ctx context.Context, cmw configmap.Watcher) *controller.Impl {
 		knativeEventingInformer := knativeEventinginformer.Get(ctx)
 		deploymentInformer := deploymentinformer.Get(ctx)
+		configMapInformer := configmapinformer.Get(ctx, common.ManifestSourceSelector)
+		secretInformer := secretinformer.Get(ctx, common.ManifestSourceSelector)
 		kubeClient := kubeclient.Get(ctx)
 		logger := logging.FromContext(ctx)
 
@@ -73,6 +79,20 @@ func NewExtendedController(generator common.ExtensionGenerator) injection.Contro
 			Handler:    controller.HandleAll(impl.EnqueueControllerOf),
 		})
 
+		// Reconcile the CRs again when the ConfigMaps or Secrets containing their manifests change.
+		// Only the ConfigMaps and Secrets labelled as manifest sources are watched.
+		c.tracker = tracker.New(impl.EnqueueKey, controller.GetTrackerLease(ctx))
+		configMapInformer.Informer().AddEventHandler(controller.HandleAll(
+			controller.EnsureTypeMeta(c.tracker.OnChanged, corev1.SchemeGroupVersion.WithKind("ConfigMap"))))
+		secretInformer.Informer().AddEventHandler(controller.HandleAll(
+			controller.EnsureTypeMeta(c.tracker.OnChanged, corev1.SchemeGroupVersion.WithKind("Secret"))))
+
+		// Reconcile all the CRs again when the in-cluster version catalog changes. The catalog is
+		// watched in the namespace of the operator, and not among the manifest sources.
+		common.WatchCatalog(ctx, cmw, func() {
+			impl.GlobalResync(knativeEventingInformer.Informer())
+		})
+
 		return impl
 	}
 }
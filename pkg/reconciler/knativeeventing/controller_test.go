@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package knativeeventing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"knative.dev/operator/pkg/reconciler/common"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	fakekubeclient "knative.dev/pkg/client/injection/kube/client/fake"
+	filteredFactory "knative.dev/pkg/client/injection/kube/informers/factory/filtered"
+	cminformer "knative.dev/pkg/configmap/informer"
+	"knative.dev/pkg/injection"
+	rtesting "knative.dev/pkg/reconciler/testing"
+	"knative.dev/pkg/system"
+
+	_ "knative.dev/operator/pkg/client/injection/client/fake"
+	_ "knative.dev/operator/pkg/client/injection/informers/operator/v1alpha1/knativeeventing/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/apps/v1/deployment/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/configmap/filtered/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/core/v1/secret/filtered/fake"
+	_ "knative.dev/pkg/client/injection/kube/informers/factory/filtered/fake"
+)
+
+func TestNewControllerWatchesCatalog(t *testing.T) {
+	os.Setenv(system.NamespaceEnvKey, "knative-operator")
+	defer os.Unsetenv(system.NamespaceEnvKey)
+	defer common.SetClusterCatalog(nil)
+
+	// The manifestival client only discovers the API of the server.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api":
+			w.Write([]byte(`{"kind":"APIVersions","versions":["v1"]}`))
+		case "/apis":
+			w.Write([]byte(`{"kind":"APIGroupList","groups":[]}`))
+		default:
+			w.Write([]byte(`{"kind":"APIResourceList","groupVersion":"v1","resources":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel, _ := rtesting.SetupFakeContextWithCancel(t, func(ctx context.Context) context.Context {
+		ctx = injection.WithConfig(ctx, &rest.Config{Host: server.URL})
+		return filteredFactory.WithSelectors(ctx, common.ManifestSourceSelector)
+	})
+	defer cancel()
+
+	// The catalog is not labelled as a manifest source.
+	kubeClient := fakekubeclient.Get(ctx)
+	if _, err := kubeClient.CoreV1().ConfigMaps("knative-operator").Create(ctx, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: common.CatalogConfigMapName, Namespace: "knative-operator"},
+		Data: map[string]string{"knative-eventing": `
+0.17.0:
+- https://example.com/v0.17.0/release.yaml
+`},
+	}, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create the catalog: %v", err)
+	}
+
+	cmw := cminformer.NewInformedWatcher(kubeClient, system.Namespace())
+	if NewController(ctx, cmw) == nil {
+		t.Fatal("Expected NewController to return a non-nil value")
+	}
+	if err := cmw.Start(ctx.Done()); err != nil {
+		t.Fatalf("Failed to start the ConfigMap watcher: %v", err)
+	}
+
+	manifests, err := common.CatalogManifests("knative-eventing", "0.17.0", "")
+	util.AssertEqual(t, err, nil)
+	util.AssertEqual(t, manifests, "https://example.com/v0.17.0/release.yaml")
+}
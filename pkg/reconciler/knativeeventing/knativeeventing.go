@@ -32,6 +32,7 @@ import (
 	"knative.dev/operator/pkg/reconciler/knativeeventing/source"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/tracker"
 )
 
 // Reconciler implements controller.Reconciler for KnativeEventing resources.
@@ -47,6 +48,8 @@ type Reconciler struct {
 	manifest mf.Manifest
 	// Platform-specific behavior to affect the transform
 	extension common.Extension
+	// tracker tracks the ConfigMaps and Secrets containing the manifests
+	tracker tracker.Interface
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -57,8 +60,8 @@ var _ knereconciler.Finalizer = (*Reconciler)(nil)
 func (r *Reconciler) FinalizeKind(ctx context.Context, original *v1alpha1.KnativeEventing) pkgreconciler.Event {
 	logger := logging.FromContext(ctx)
 
-	// Clean up the cache, if the Serving CR is deleted.
-	common.ClearCache()
+	// Evict the manifests of this CR from the cache, since it is deleted.
+	common.EvictCache(original)
 
 	// List all KnativeEventings to determine if cluster-scoped resources should be deleted.
 	kes, err := r.operatorClientSet.OperatorV1alpha1().KnativeEventings("").List(ctx, metav1.ListOptions{})
@@ -94,6 +97,10 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ke *v1alpha1.KnativeEven
 
 	logger.Infow("Reconciling KnativeEventing", "status", ke.Status)
 
+	if err := common.TrackManifestSources(r.tracker, ke); err != nil {
+		return err
+	}
+
 	if err := common.IsVersionValidMigrationEligible(ke); err != nil {
 		ke.Status.MarkVersionMigrationNotEligible(err.Error())
 		return nil
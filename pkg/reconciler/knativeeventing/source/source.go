@@ -18,21 +18,18 @@ package source
 
 import (
 	"context"
-	"os"
-	"path/filepath"
 	"strings"
 
 	mf "github.com/manifestival/manifestival"
-	"golang.org/x/mod/semver"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"knative.dev/operator/pkg/reconciler/common"
 )
 
-func getSource(manifest *mf.Manifest, path string) error {
+func getSource(ctx context.Context, instance v1alpha1.KComponent, manifest *mf.Manifest, path string) error {
 	if path == "" {
 		return nil
 	}
-	m, err := common.FetchManifest(path)
+	m, err := common.FetchManifest(ctx, instance, path)
 	if err != nil {
 		return err
 	}
@@ -40,80 +37,46 @@ func getSource(manifest *mf.Manifest, path string) error {
 	return nil
 }
 
-func getSourcePath(version string, ke *v1alpha1.KnativeEventing) string {
-	if ke.Spec.Source == nil {
-		// If no eventing source is defined, return an empty string.
-		return ""
-	}
-
-	koDataDir := os.Getenv(common.KoEnvKey)
-	sourceVersion := common.LATEST_VERSION
-	if !strings.EqualFold(version, common.LATEST_VERSION) {
-		sourceVersion = semver.MajorMinor(common.SanitizeSemver(version))[1:]
-	}
-
-	// This line can make sure a valid available source version is returned.
-	sourcePath := filepath.Join(koDataDir, "eventing-source", sourceVersion)
-	var urls []string
-
-	if ke.Spec.Source.Awssqs.Enabled {
-		url := filepath.Join(sourcePath, "awssqs")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Ceph.Enabled {
-		url := filepath.Join(sourcePath, "ceph")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Couchdb.Enabled {
-		url := filepath.Join(sourcePath, "couchdb")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Github.Enabled {
-		url := filepath.Join(sourcePath, "github")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Gitlab.Enabled {
-		url := filepath.Join(sourcePath, "gitlab")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Kafka.Enabled {
-		url := filepath.Join(sourcePath, "kafka")
+func getSourcePath(version string, names []string) (string, error) {
+	urls := make([]string, 0, len(names))
+	for _, name := range names {
+		url, err := common.AlternativeManifests(common.SourceCatalog, version, name)
+		if err != nil {
+			return "", err
+		}
 		urls = append(urls, url)
 	}
-	if ke.Spec.Source.Natss.Enabled {
-		url := filepath.Join(sourcePath, "natss")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Prometheus.Enabled {
-		url := filepath.Join(sourcePath, "prometheus")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Rabbitmq.Enabled {
-		url := filepath.Join(sourcePath, "rabbitmq")
-		urls = append(urls, url)
-	}
-	if ke.Spec.Source.Redis.Enabled {
-		url := filepath.Join(sourcePath, "redis")
-		urls = append(urls, url)
-	}
-	return strings.Join(urls, common.COMMA)
+	return strings.Join(urls, common.COMMA), nil
 }
 
 // AppendTargetSources appends the manifests of the eventing sources to be installed
 func AppendTargetSources(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
 	version := common.TargetVersion(instance)
-	sourcePath := getSourcePath(version, convertToKE(instance))
-	return getSource(manifest, sourcePath)
+	sourcePath, err := getSourcePath(version, convertToKE(instance).Spec.EnabledSources())
+	if err != nil {
+		return err
+	}
+	return getSource(ctx, instance, manifest, sourcePath)
 }
 
-// AppendInstalledSources appends the installed manifests of the eventing sources
+// AppendInstalledSources appends the installed manifests of the eventing sources, which were enabled
+// for the installed release
 func AppendInstalledSources(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
 	version := instance.GetStatus().GetVersion()
 	if version == "" {
 		version = common.TargetVersion(instance)
 	}
-	sourcePath := getSourcePath(version, convertToKE(instance))
-	return getSource(manifest, sourcePath)
+	// The sources enabled in the spec are used if the status does not record them.
+	ke := convertToKE(instance)
+	names := ke.Status.Sources
+	if names == nil {
+		names = ke.Spec.EnabledSources()
+	}
+	sourcePath, err := getSourcePath(version, names)
+	if err != nil {
+		return err
+	}
+	return getSource(ctx, instance, manifest, sourcePath)
 }
 
 func convertToKE(instance v1alpha1.KComponent) *v1alpha1.KnativeEventing {
@@ -101,6 +101,24 @@ func TestAppendInstalledSources(t *testing.T) {
 			os.Getenv(common.KoEnvKey) + "/eventing-source/0.22/prometheus" + common.COMMA +
 			os.Getenv(common.KoEnvKey) + "/eventing-source/0.22/rabbitmq",
 		expectedErr: nil,
+	}, {
+		name: "Sources recorded in the status rather than the ones enabled in the spec",
+		instance: eventingv1alpha1.KnativeEventing{
+			Spec: eventingv1alpha1.KnativeEventingSpec{
+				Source: &eventingv1alpha1.SourceConfigs{
+					Kafka: eventingv1alpha1.KafkaSourceConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+			Status: eventingv1alpha1.KnativeEventingStatus{
+				Version: "0.22",
+				Sources: []string{"github", "redis"},
+			},
+		},
+		expectedIngressPath: os.Getenv(common.KoEnvKey) + "/eventing-source/0.22/github" + common.COMMA +
+			os.Getenv(common.KoEnvKey) + "/eventing-source/0.22/redis",
+		expectedErr: nil,
 	}, {
 		name: "No source is enabled",
 		instance: eventingv1alpha1.KnativeEventing{
@@ -215,7 +233,7 @@ func TestAppendTargetSources(t *testing.T) {
 				},
 			},
 		},
-		expectedErr: fmt.Errorf("stat testdata/kodata/eventing-source/0.12/awssqs: no such file or directory"),
+		expectedErr: fmt.Errorf("the version 0.12 of eventing-source/awssqs is not available in the catalog"),
 	}, {
 		name: "Get the latest target source when the directory latest is unavailable",
 		instance: eventingv1alpha1.KnativeEventing{
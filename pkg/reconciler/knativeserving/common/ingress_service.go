@@ -25,13 +25,33 @@ import (
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 )
 
-// IngressServiceTransform pins the namespace to istio-system for the service named knative-local-gateway.
-// It also removes the OwnerReference to the operator, as they are in different namespaces, which is
-// invalid in Kubernetes 1.20+.
+// IngressServiceTransform pins the namespace to the local gateway namespace of the spec, or istio-system,
+// for the service named knative-local-gateway. The namespace of the local gateway set in the istio config
+// takes precedence. It also removes the OwnerReference to the operator, as they are in different namespaces,
+// which is invalid in Kubernetes 1.20+.
 func IngressServiceTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	return ingressServiceTransform(ks, ks.Spec.LocalGatewayNamespace())
+}
+
+// InstalledIngressServiceTransform pins the namespace of the knative-local-gateway Service of the
+// installed manifest to the local gateway namespace recorded in the status, so that the Service left
+// in a previous namespace is deleted. The local gateway namespace of the spec is used if nothing has
+// been recorded yet.
+func InstalledIngressServiceTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	ns := ks.Status.LocalGatewayNamespace
+	if ks.Status.Ingresses == nil {
+		ns = ks.Spec.LocalGatewayNamespace()
+	}
+	return ingressServiceTransform(ks, ns)
+}
+
+func ingressServiceTransform(ks *v1alpha1.KnativeServing, localGatewayNamespace string) mf.Transformer {
 	return func(u *unstructured.Unstructured) error {
 		if u.GetAPIVersion() == "v1" && u.GetKind() == "Service" && u.GetName() == "knative-local-gateway" {
 			u.SetNamespace("istio-system")
+			if localGatewayNamespace != "" {
+				u.SetNamespace(localGatewayNamespace)
+			}
 			u.SetOwnerReferences(nil)
 			config := ks.GetSpec().GetConfig()
 			if data, ok := config["istio"]; ok {
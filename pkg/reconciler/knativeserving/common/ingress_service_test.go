@@ -122,6 +122,45 @@ func TestIngressServiceTransform(t *testing.T) {
 			},
 		},
 		expected: true,
+	}, {
+		name:              "IstioNotUnderDefaultNS with localGatewayNamespace",
+		namespace:         "test-namespace",
+		serviceName:       "knative-local-gateway",
+		expectedNamespace: "istio-gateways",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio: servingv1alpha1.IstioIngressConfiguration{
+						Enabled:               true,
+						LocalGatewayNamespace: "istio-gateways",
+					},
+				},
+			},
+		},
+		expected: true,
+	}, {
+		name:              "IstioNotUnderDefaultNS with localGatewayNamespace and config-istio",
+		namespace:         "test-namespace",
+		serviceName:       "knative-local-gateway",
+		expectedNamespace: "istio-system-1",
+		instance: &servingv1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-instance",
+				Namespace: "test-namespace",
+			},
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{
+					Config: map[string]map[string]string{"config-istio": {"local-gateway.test-namespace.knative-local-gateway": "knative-local-gateway.istio-system-1.svc.cluster.local"}},
+				},
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio: servingv1alpha1.IstioIngressConfiguration{
+						Enabled:               true,
+						LocalGatewayNamespace: "istio-gateways",
+					},
+				},
+			},
+		},
+		expected: true,
 	}}
 
 	for _, tt := range tests {
@@ -134,6 +173,44 @@ func TestIngressServiceTransform(t *testing.T) {
 	}
 }
 
+func TestInstalledIngressServiceTransform(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            servingv1alpha1.KnativeServingStatus
+		expectedNamespace string
+	}{{
+		name:              "RecordedLocalGatewayNamespace",
+		status:            servingv1alpha1.KnativeServingStatus{Ingresses: []string{"istio"}, LocalGatewayNamespace: "istio-gateways-old"},
+		expectedNamespace: "istio-gateways-old",
+	}, {
+		name:              "RecordedDefaultNamespace",
+		status:            servingv1alpha1.KnativeServingStatus{Ingresses: []string{"istio"}},
+		expectedNamespace: "istio-system",
+	}, {
+		name:              "UnrecordedLocalGatewayNamespace",
+		expectedNamespace: "istio-gateways",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					Ingress: &servingv1alpha1.IngressConfigs{
+						Istio: servingv1alpha1.IstioIngressConfiguration{
+							Enabled:               true,
+							LocalGatewayNamespace: "istio-gateways",
+						},
+					},
+				},
+				Status: tt.status,
+			}
+			service := makeIngressService(t, "knative-local-gateway", "test-namespace")
+			InstalledIngressServiceTransform(instance)(service)
+			util.AssertEqual(t, service.GetNamespace(), tt.expectedNamespace)
+		})
+	}
+}
+
 func makeIngressService(t *testing.T, name, ns string) *unstructured.Unstructured {
 	service := &v1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,199 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+	"sigs.k8s.io/yaml"
+)
+
+const domainConfigMapName = "config-domain"
+
+var (
+	defaultDomainJob = mf.All(mf.ByKind("Job"), mf.ByLabel("app", "default-domain"))
+
+	// magicDNSSuffixes are the suffixes of the domains, which the default-domain Job adds to the
+	// config-domain ConfigMap.
+	magicDNSSuffixes = []string{".sslip.io", ".xip.io"}
+)
+
+// AppendTargetDomain appends the manifests of the default-domain Job to be installed, if the magic
+// DNS is enabled.
+func AppendTargetDomain(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	if !ks.Spec.MagicDNSEnabled() {
+		return nil
+	}
+	return common.AppendAlternativeManifests(ctx, manifest, instance, common.DefaultDomainCatalog, common.TargetVersion(instance))
+}
+
+// AppendInstalledDomain appends the installed manifests of the default-domain Job, if the magic DNS
+// was enabled for the installed release.
+func AppendInstalledDomain(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	if !ks.Status.MagicDNS {
+		return nil
+	}
+	version := instance.GetStatus().GetVersion()
+	if version == "" {
+		version = common.TargetVersion(instance)
+	}
+	return common.AppendAlternativeManifests(ctx, manifest, instance, common.DefaultDomainCatalog, version)
+}
+
+// ExistingJobTransform keeps the template of the default-domain Job as it is, if the Job already
+// exists. The template of a Job is immutable, and the Job only has to run once per release, whose
+// version is part of its name.
+func ExistingJobTransform(client mf.Client) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if !defaultDomainJob(u) {
+			return nil
+		}
+		current, err := client.Get(u)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		template, _, err := unstructured.NestedMap(current.Object, "spec", "template")
+		if err != nil {
+			return err
+		}
+		return unstructured.SetNestedMap(u.Object, template, "spec", "template")
+	}
+}
+
+// RemoveMagicDNS removes the domains, which the default-domain Job added to the config-domain
+// ConfigMap, if the magic DNS was enabled for the installed release, but is disabled in the spec.
+// Applying the ConfigMap would keep them, as they are not part of the manifest. The domains set in
+// the spec.config are left as they are.
+func RemoveMagicDNS(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	if !ks.Status.MagicDNS || ks.Spec.MagicDNSEnabled() {
+		return nil
+	}
+	for _, u := range manifest.Filter(mf.ByKind("ConfigMap"), mf.ByName(domainConfigMapName)).Resources() {
+		current, err := manifest.Client.Get(&u)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		data, _, err := unstructured.NestedStringMap(current.Object, "data")
+		if err != nil {
+			return err
+		}
+		removed := false
+		for domain := range data {
+			if isMagicDNS(domain) && !hasDomainConfig(ks, domain) {
+				delete(data, domain)
+				removed = true
+			}
+		}
+		if !removed {
+			continue
+		}
+		if err := unstructured.SetNestedStringMap(current.Object, data, "data"); err != nil {
+			return err
+		}
+		if err := manifest.Client.Update(current); err != nil {
+			return fmt.Errorf("failed to remove the magic DNS from %s: %w", domainConfigMapName, err)
+		}
+	}
+	return nil
+}
+
+// isMagicDNS returns whether the domain is one of the magic DNS.
+func isMagicDNS(domain string) bool {
+	for _, suffix := range magicDNSSuffixes {
+		if strings.HasSuffix(domain, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Transformers returns the transformers of the domains, if configured.
+func Transformers(ks *v1alpha1.KnativeServing) []mf.Transformer {
+	if ks.Spec.Domain == nil {
+		return nil
+	}
+	return []mf.Transformer{domainTransform(ks)}
+}
+
+// domainTransform adds the static domain and the domains per selector to the config-domain
+// ConfigMap. The domains set in the spec.config are left as they are.
+func domainTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != domainConfigMapName {
+			return nil
+		}
+		config := ks.Spec.Domain
+		if config.Static != "" && config.MagicDNS {
+			return fmt.Errorf("the static domain %s cannot be set along with the magic DNS", config.Static)
+		}
+
+		data, _, err := unstructured.NestedStringMap(u.Object, "data")
+		if err != nil {
+			return err
+		}
+		if data == nil {
+			data = map[string]string{}
+		}
+		if config.Static != "" && !hasDomainConfig(ks, config.Static) {
+			// The default domain has no selector.
+			data[config.Static] = ""
+		}
+		for domain, labels := range config.Selectors {
+			if len(labels) == 0 {
+				return fmt.Errorf("the domain %s requires a selector", domain)
+			}
+			if domain == config.Static {
+				return fmt.Errorf("the domain %s is both static and selected", domain)
+			}
+			if hasDomainConfig(ks, domain) {
+				continue
+			}
+			selector, err := yaml.Marshal(map[string]map[string]string{"selector": labels})
+			if err != nil {
+				return err
+			}
+			data[domain] = string(selector)
+		}
+		return unstructured.SetNestedStringMap(u.Object, data, "data")
+	}
+}
+
+// hasDomainConfig returns whether the domain is set in the spec.config.
+func hasDomainConfig(ks *v1alpha1.KnativeServing, domain string) bool {
+	for _, name := range []string{domainConfigMapName, "domain"} {
+		if _, ok := ks.Spec.Config[name][domain]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package domain
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"github.com/manifestival/manifestival/fake"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestAppendTargetDomain(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	tests := []struct {
+		name         string
+		instance     *servingv1alpha1.KnativeServing
+		expectedPath string
+		expectErr    bool
+	}{{
+		name: "Job of the same patch version",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.0"},
+				Domain:     &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+			},
+		},
+		expectedPath: os.Getenv(common.KoEnvKey) + "/default-domain/0.22.0",
+	}, {
+		name: "Job of the latest patch version of the same minor release",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.3"},
+				Domain:     &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+			},
+		},
+		expectedPath: os.Getenv(common.KoEnvKey) + "/default-domain/0.22.0",
+	}, {
+		name: "Static domain",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.0"},
+				Domain:     &servingv1alpha1.DomainConfiguration{Static: "example.com"},
+			},
+		},
+	}, {
+		name: "Unavailable default-domain Job",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.16.1"},
+				Domain:     &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _ := mf.ManifestFrom(mf.Slice{})
+			err := AppendTargetDomain(context.TODO(), &manifest, tt.instance)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectedPath == "" {
+				util.AssertEqual(t, len(manifest.Resources()), 0)
+				return
+			}
+			util.AssertEqual(t, util.DeepMatchWithPath(manifest, tt.expectedPath), true)
+		})
+	}
+}
+
+func TestAppendInstalledDomain(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	// The magic DNS and the version recorded in the status are used, rather than the ones of the spec.
+	instance := &servingv1alpha1.KnativeServing{
+		Spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{Version: "0.16.1"},
+		},
+		Status: servingv1alpha1.KnativeServingStatus{
+			Version:  "0.22.0",
+			MagicDNS: true,
+		},
+	}
+	manifest, _ := mf.ManifestFrom(mf.Slice{})
+	util.AssertEqual(t, AppendInstalledDomain(context.TODO(), &manifest, instance), nil)
+	util.AssertEqual(t, util.DeepMatchWithPath(manifest, os.Getenv(common.KoEnvKey)+"/default-domain/0.22.0"), true)
+
+	instance = &servingv1alpha1.KnativeServing{
+		Spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.0"},
+			Domain:     &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+		},
+	}
+	manifest, _ = mf.ManifestFrom(mf.Slice{})
+	util.AssertEqual(t, AppendInstalledDomain(context.TODO(), &manifest, instance), nil)
+	util.AssertEqual(t, len(manifest.Resources()), 0)
+}
+
+func TestDomainTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		domain    *servingv1alpha1.DomainConfiguration
+		config    map[string]map[string]string
+		expected  map[string]string
+		expectErr bool
+	}{{
+		name:     "No domain",
+		expected: map[string]string{"_example": "example"},
+	}, {
+		name: "Static domain and selectors",
+		domain: &servingv1alpha1.DomainConfiguration{
+			Static: "example.com",
+			Selectors: map[string]map[string]string{
+				"example.org":       {"app": "nonprofit"},
+				"svc.cluster.local": {"app": "secret"},
+			},
+		},
+		expected: map[string]string{
+			"_example":          "example",
+			"example.com":       "",
+			"example.org":       "selector:\n  app: nonprofit\n",
+			"svc.cluster.local": "selector:\n  app: secret\n",
+		},
+	}, {
+		name:     "Magic DNS",
+		domain:   &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+		expected: map[string]string{"_example": "example"},
+	}, {
+		name: "Keep the domains of the spec.config",
+		domain: &servingv1alpha1.DomainConfiguration{
+			Static:    "example.com",
+			Selectors: map[string]map[string]string{"example.org": {"app": "nonprofit"}},
+		},
+		config: map[string]map[string]string{
+			"domain": {"example.org": "selector:\n  app: custom\n"},
+		},
+		expected: map[string]string{
+			"_example":    "example",
+			"example.com": "",
+		},
+	}, {
+		name:      "Static domain along with magic DNS",
+		domain:    &servingv1alpha1.DomainConfiguration{Static: "example.com", MagicDNS: true},
+		expectErr: true,
+	}, {
+		name: "Selected domain without selector",
+		domain: &servingv1alpha1.DomainConfiguration{
+			Selectors: map[string]map[string]string{"example.org": {}},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					CommonSpec: servingv1alpha1.CommonSpec{Config: tt.config},
+					Domain:     tt.domain,
+				},
+			}
+			configMap := unstructured.Unstructured{}
+			configMap.SetAPIVersion("v1")
+			configMap.SetKind("ConfigMap")
+			configMap.SetName("config-domain")
+			configMap.Object["data"] = map[string]interface{}{"_example": "example"}
+			manifest, _ := mf.ManifestFrom(mf.Slice{configMap})
+
+			manifest, err := manifest.Transform(Transformers(instance)...)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+			data, _, _ := unstructured.NestedStringMap(manifest.Resources()[0].Object, "data")
+			util.AssertDeepEqual(t, data, tt.expected)
+		})
+	}
+}
+
+func TestExistingJobTransform(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	client := fake.New()
+	manifest, err := mf.NewManifest(os.Getenv(common.KoEnvKey)+"/default-domain/0.22.0", mf.UseClient(client))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	jobImage := func(m mf.Manifest) string {
+		containers, _, _ := unstructured.NestedSlice(m.Filter(mf.ByKind("Job")).Resources()[0].Object,
+			"spec", "template", "spec", "containers")
+		return containers[0].(map[string]interface{})["image"].(string)
+	}
+	changeImage := func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Job" {
+			return nil
+		}
+		containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+		containers[0].(map[string]interface{})["image"] = "example.com/default-domain:v0.22.0"
+		return unstructured.SetNestedSlice(u.Object, containers, "spec", "template", "spec", "containers")
+	}
+
+	// The template of a new Job is left as it is.
+	transformed, err := manifest.Transform(changeImage, ExistingJobTransform(client))
+	if err != nil {
+		t.Fatalf("Failed to transform manifest: %v", err)
+	}
+	util.AssertEqual(t, jobImage(transformed), "example.com/default-domain:v0.22.0")
+
+	// The template of an existing Job is kept.
+	if err := manifest.Apply(); err != nil {
+		t.Fatalf("Failed to apply manifest: %v", err)
+	}
+	transformed, err = manifest.Transform(changeImage, ExistingJobTransform(client))
+	if err != nil {
+		t.Fatalf("Failed to transform manifest: %v", err)
+	}
+	util.AssertEqual(t, jobImage(transformed), jobImage(manifest))
+}
+
+func TestRemoveMagicDNS(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     *servingv1alpha1.DomainConfiguration
+		config   map[string]map[string]string
+		status   bool
+		expected map[string]string
+	}{{
+		name:   "Disabled magic DNS",
+		status: true,
+		expected: map[string]string{
+			"_example":    "example",
+			"example.com": "",
+		},
+	}, {
+		name:   "Enabled magic DNS",
+		spec:   &servingv1alpha1.DomainConfiguration{MagicDNS: true},
+		status: true,
+		expected: map[string]string{
+			"_example":          "example",
+			"example.com":       "",
+			"10.0.0.1.sslip.io": "",
+		},
+	}, {
+		name: "Magic DNS not installed",
+		expected: map[string]string{
+			"_example":          "example",
+			"example.com":       "",
+			"10.0.0.1.sslip.io": "",
+		},
+	}, {
+		name:   "Keep the domains of the spec.config",
+		config: map[string]map[string]string{"config-domain": {"10.0.0.1.sslip.io": ""}},
+		status: true,
+		expected: map[string]string{
+			"_example":          "example",
+			"example.com":       "",
+			"10.0.0.1.sslip.io": "",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					CommonSpec: servingv1alpha1.CommonSpec{Config: tt.config},
+					Domain:     tt.spec,
+				},
+				Status: servingv1alpha1.KnativeServingStatus{MagicDNS: tt.status},
+			}
+			configMap := unstructured.Unstructured{}
+			configMap.SetAPIVersion("v1")
+			configMap.SetKind("ConfigMap")
+			configMap.SetName("config-domain")
+			configMap.SetNamespace("knative-serving")
+			configMap.Object["data"] = map[string]interface{}{"_example": "example"}
+			client := fake.New()
+			manifest, _ := mf.ManifestFrom(mf.Slice{configMap}, mf.UseClient(client))
+
+			// The default-domain Job added its domain to the ConfigMap.
+			current := configMap.DeepCopy()
+			current.Object["data"] = map[string]interface{}{"_example": "example", "example.com": "", "10.0.0.1.sslip.io": ""}
+			if err := client.Create(current); err != nil {
+				t.Fatalf("Failed to create ConfigMap: %v", err)
+			}
+
+			util.AssertEqual(t, RemoveMagicDNS(context.TODO(), &manifest, instance), nil)
+			current, err := client.Get(&configMap)
+			if err != nil {
+				t.Fatalf("Failed to get ConfigMap: %v", err)
+			}
+			data, _, _ := unstructured.NestedStringMap(current.Object, "data")
+			util.AssertDeepEqual(t, data, tt.expected)
+		})
+	}
+}
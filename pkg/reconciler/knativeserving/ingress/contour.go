@@ -20,11 +20,121 @@ import (
 	"context"
 
 	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
 )
 
-var contourFilter = ingressFilter("contour")
+const (
+	contourConfigMapName     = "config-contour"
+	contourEnvoyServiceName  = "envoy"
+	contourExternalNamespace = "contour-external"
+	contourInternalNamespace = "contour-internal"
+	contourDefaultVisibility = `
+ExternalIP:
+  class: contour-external
+  service: contour-external/envoy
+ClusterLocal:
+  class: contour-internal
+  service: contour-internal/envoy
+`
+)
+
+var (
+	contourFilter = ingressFilter("contour")
+
+	contourDependencies = []common.Dependency{
+		{Name: "Contour", Groups: []string{"projectcontour.io"}, Kind: "HTTPProxy"},
+	}
+)
 
 func contourTransformers(ctx context.Context, instance *v1alpha1.KnativeServing) []mf.Transformer {
-	return nil
+	return []mf.Transformer{
+		configureContourVisibility(instance),
+		configureEnvoyServiceType(instance),
+	}
+}
+
+// configureContourVisibility sets the Contour class and the envoy Service of the external and the
+// cluster-local visibilities in the config-contour ConfigMap.
+func configureContourVisibility(instance *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != contourConfigMapName || !hasProviderLabel(u) {
+			return nil
+		}
+		external, internal := contourVisibilities(instance)
+		if !hasContourVisibility(external) && !hasContourVisibility(internal) {
+			// Do nothing if no visibility is configured.
+			return nil
+		}
+		return updateVisibility(u, contourDefaultVisibility, func(visibility map[string]map[string]string) error {
+			setContourVisibility(visibility, "ExternalIP", external)
+			setContourVisibility(visibility, "ClusterLocal", internal)
+			return nil
+		})
+	}
+}
+
+// configureEnvoyServiceType configures the service type of the envoy Services of the Contour
+// installations, if they are part of the manifests. The shipped net-contour release contains
+// none, so it only applies to the Contour installations added to the manifests, e.g. with
+// spec.additionalManifests.
+func configureEnvoyServiceType(instance *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "Service" || u.GetName() != contourEnvoyServiceName || !hasProviderLabel(u) {
+			return nil
+		}
+		external, internal := contourVisibilities(instance)
+		switch u.GetNamespace() {
+		case contourNamespace(external, contourExternalNamespace):
+			return setServiceType(u, external.ServiceType)
+		case contourNamespace(internal, contourInternalNamespace):
+			return setServiceType(u, internal.ServiceType)
+		}
+		return nil
+	}
+}
+
+func contourVisibilities(instance *v1alpha1.KnativeServing) (external, internal *v1alpha1.ContourVisibilityConfiguration) {
+	external, internal = &v1alpha1.ContourVisibilityConfiguration{}, &v1alpha1.ContourVisibilityConfiguration{}
+	if instance.Spec.Ingress == nil {
+		return external, internal
+	}
+	if instance.Spec.Ingress.Contour.External != nil {
+		external = instance.Spec.Ingress.Contour.External
+	}
+	if instance.Spec.Ingress.Contour.Internal != nil {
+		internal = instance.Spec.Ingress.Contour.Internal
+	}
+	return external, internal
+}
+
+func hasContourVisibility(config *v1alpha1.ContourVisibilityConfiguration) bool {
+	return config.Class != "" || config.Namespace != ""
+}
+
+// setContourVisibility overrides the settings of a visibility with the configured class and
+// namespace of the Contour installation.
+func setContourVisibility(visibility map[string]map[string]string, key string, config *v1alpha1.ContourVisibilityConfiguration) {
+	if !hasContourVisibility(config) {
+		return
+	}
+	settings := visibility[key]
+	if settings == nil {
+		settings = map[string]string{}
+		visibility[key] = settings
+	}
+	if config.Class != "" {
+		settings["class"] = config.Class
+	}
+	if config.Namespace != "" {
+		settings["service"] = config.Namespace + "/" + contourEnvoyServiceName
+	}
+}
+
+func contourNamespace(config *v1alpha1.ContourVisibilityConfiguration, defaultNamespace string) string {
+	if config.Namespace != "" {
+		return config.Namespace
+	}
+	return defaultNamespace
 }
@@ -20,12 +20,242 @@ import (
 	"context"
 	"testing"
 
+	mf "github.com/manifestival/manifestival"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	util "knative.dev/operator/pkg/reconciler/common/testing"
+	"sigs.k8s.io/yaml"
 )
 
 func TestContourTransformers(t *testing.T) {
 	instance := &servingv1alpha1.KnativeServing{}
 	transformer := contourTransformers(context.TODO(), instance)
-	util.AssertEqual(t, len(transformer), 0)
+	util.AssertEqual(t, len(transformer), 2)
+}
+
+func TestConfigureContourVisibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		instance  *servingv1alpha1.KnativeServing
+		dropLabel bool
+		expected  map[string]map[string]string
+	}{{
+		name: "Keep the default visibility",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+	}, {
+		name: "Override the class and the namespace",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							Class:     "public",
+							Namespace: "contour-public",
+						},
+						Internal: &servingv1alpha1.ContourVisibilityConfiguration{
+							Class:     "private",
+							Namespace: "contour-private",
+						},
+					},
+				},
+			},
+		},
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "public",
+				"service": "contour-public/envoy",
+			},
+			"ClusterLocal": {
+				"class":   "private",
+				"service": "contour-private/envoy",
+			},
+		},
+	}, {
+		name: "Override the external class only",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							Class: "public",
+						},
+					},
+				},
+			},
+		},
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "public",
+				"service": "contour-external/envoy",
+			},
+			"ClusterLocal": {
+				"class":   "contour-internal",
+				"service": "contour-internal/envoy",
+			},
+		},
+	}, {
+		name: "Do not transform without the ingress provider label",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							Class: "public",
+						},
+					},
+				},
+			},
+		},
+		dropLabel: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.22/net-contour.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+
+			manifest, err = manifest.Transform(configureContourVisibility(tt.instance))
+			if err != nil {
+				t.Fatalf("Failed to transform manifest: %v", err)
+			}
+			for _, u := range manifest.Filter(mf.ByKind("ConfigMap")).Resources() {
+				data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+				value, ok := data[visibilityKey]
+				util.AssertEqual(t, ok, tt.expected != nil)
+				if !ok {
+					continue
+				}
+				visibility := map[string]map[string]string{}
+				if err := yaml.Unmarshal([]byte(value), &visibility); err != nil {
+					t.Fatalf("Failed to parse the visibility: %v", err)
+				}
+				util.AssertDeepEqual(t, visibility, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigureEnvoyServiceType(t *testing.T) {
+	tests := []struct {
+		name      string
+		instance  *servingv1alpha1.KnativeServing
+		namespace string
+		label     string
+		expected  v1.ServiceType
+		expectErr bool
+	}{{
+		name: "External envoy in the default namespace",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							ServiceType: v1.ServiceTypeNodePort,
+						},
+					},
+				},
+			},
+		},
+		namespace: "contour-external",
+		label:     "contour",
+		expected:  v1.ServiceTypeNodePort,
+	}, {
+		name: "Internal envoy in a custom namespace",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						Internal: &servingv1alpha1.ContourVisibilityConfiguration{
+							Namespace:   "contour-private",
+							ServiceType: v1.ServiceTypeClusterIP,
+						},
+					},
+				},
+			},
+		},
+		namespace: "contour-private",
+		label:     "contour",
+		expected:  v1.ServiceTypeClusterIP,
+	}, {
+		name: "Envoy of another Contour installation",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							ServiceType: v1.ServiceTypeNodePort,
+						},
+					},
+				},
+			},
+		},
+		namespace: "projectcontour",
+		label:     "contour",
+	}, {
+		name: "Envoy without the ingress provider label",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							ServiceType: v1.ServiceTypeNodePort,
+						},
+					},
+				},
+			},
+		},
+		namespace: "contour-external",
+	}, {
+		name: "Unknown service type",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour: servingv1alpha1.ContourIngressConfiguration{
+						Enabled: true,
+						External: &servingv1alpha1.ContourVisibilityConfiguration{
+							ServiceType: "Invalid",
+						},
+					},
+				},
+			},
+		},
+		namespace: "contour-external",
+		label:     "contour",
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := makeIngressResource(t, contourEnvoyServiceName, tt.namespace, tt.label)
+			err := configureEnvoyServiceType(tt.instance)(u)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+			serviceType, _, _ := unstructured.NestedString(u.Object, "spec", "type")
+			util.AssertEqual(t, serviceType, string(tt.expected))
+		})
+	}
 }
@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+)
+
+const gatewayAPIConfigMapName = "config-gateway"
+
+var (
+	gatewayAPIFilter = ingressFilter("net-gateway-api")
+
+	// The Gateway API moved from the networking.x-k8s.io group to gateway.networking.k8s.io.
+	gatewayAPIGroups       = []string{"gateway.networking.k8s.io", "networking.x-k8s.io"}
+	gatewayAPIDependencies = []common.Dependency{
+		{Name: "Gateway API", Groups: gatewayAPIGroups, Kind: "Gateway"},
+		{Name: "Gateway API", Groups: gatewayAPIGroups, Kind: "HTTPRoute"},
+	}
+)
+
+func gatewayAPITransformers(ctx context.Context, instance *v1alpha1.KnativeServing) []mf.Transformer {
+	return []mf.Transformer{configureGatewayReferences(instance)}
+}
+
+// configureGatewayReferences points the config-gateway ConfigMap of net-gateway-api at the
+// Gateways referenced in the spec. The visibilities without a reference are left as they are.
+func configureGatewayReferences(instance *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != gatewayAPIConfigMapName || !hasProviderLabel(u) {
+			return nil
+		}
+		config := instance.Spec.Ingress.GatewayAPI
+		if config.ExternalGateway == nil && config.LocalGateway == nil {
+			// Do nothing if no Gateway is configured.
+			return nil
+		}
+
+		return updateVisibility(u, "", func(visibility map[string]map[string]string) error {
+			if err := setGatewayReference(visibility, "ExternalIP", config.ExternalGateway); err != nil {
+				return err
+			}
+			return setGatewayReference(visibility, "ClusterLocal", config.LocalGateway)
+		})
+	}
+}
+
+// setGatewayReference overrides the settings of a visibility with the non-empty fields of ref.
+func setGatewayReference(visibility map[string]map[string]string, key string, ref *v1alpha1.GatewayReference) error {
+	if ref == nil {
+		return nil
+	}
+	if !isNamespacedName(ref.Gateway) {
+		return fmt.Errorf("invalid gateway %q of %s, expected namespace/name", ref.Gateway, key)
+	}
+	if ref.Service != "" && !isNamespacedName(ref.Service) {
+		return fmt.Errorf("invalid service %q of %s, expected namespace/name", ref.Service, key)
+	}
+	settings := visibility[key]
+	if settings == nil {
+		settings = map[string]string{}
+		visibility[key] = settings
+	}
+	settings["gateway"] = ref.Gateway
+	if ref.Class != "" {
+		settings["class"] = ref.Class
+	}
+	if ref.Service != "" {
+		settings["service"] = ref.Service
+	}
+	return nil
+}
+
+func isNamespacedName(s string) bool {
+	parts := strings.Split(s, "/")
+	return len(parts) == 2 && parts[0] != "" && parts[1] != ""
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+	"sigs.k8s.io/yaml"
+)
+
+func TestConfigureGatewayReferences(t *testing.T) {
+	tests := []struct {
+		name      string
+		instance  *servingv1alpha1.KnativeServing
+		dropLabel bool
+		expected  map[string]map[string]string
+		expectErr bool
+	}{{
+		name: "Keep the default Gateways",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "istio",
+				"gateway": "istio-system/knative-gateway",
+				"service": "istio-system/istio-ingressgateway",
+			},
+			"ClusterLocal": {
+				"class":   "istio",
+				"gateway": "istio-system/knative-local-gateway",
+				"service": "istio-system/knative-local-gateway",
+			},
+		},
+	}, {
+		name: "Override both Gateways",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+						ExternalGateway: &servingv1alpha1.GatewayReference{
+							Class:   "contour",
+							Gateway: "contour-external/knative-external",
+							Service: "contour-external/envoy",
+						},
+						LocalGateway: &servingv1alpha1.GatewayReference{
+							Class:   "contour",
+							Gateway: "contour-internal/knative-local",
+							Service: "contour-internal/envoy",
+						},
+					},
+				},
+			},
+		},
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "contour",
+				"gateway": "contour-external/knative-external",
+				"service": "contour-external/envoy",
+			},
+			"ClusterLocal": {
+				"class":   "contour",
+				"gateway": "contour-internal/knative-local",
+				"service": "contour-internal/envoy",
+			},
+		},
+	}, {
+		name: "Override the Gateway only",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+						ExternalGateway: &servingv1alpha1.GatewayReference{
+							Gateway: "gateways/external",
+						},
+					},
+				},
+			},
+		},
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "istio",
+				"gateway": "gateways/external",
+				"service": "istio-system/istio-ingressgateway",
+			},
+			"ClusterLocal": {
+				"class":   "istio",
+				"gateway": "istio-system/knative-local-gateway",
+				"service": "istio-system/knative-local-gateway",
+			},
+		},
+	}, {
+		name: "Do not transform without the ingress provider label",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+						ExternalGateway: &servingv1alpha1.GatewayReference{
+							Gateway: "gateways/external",
+						},
+					},
+				},
+			},
+		},
+		dropLabel: true,
+		expected: map[string]map[string]string{
+			"ExternalIP": {
+				"class":   "istio",
+				"gateway": "istio-system/knative-gateway",
+				"service": "istio-system/istio-ingressgateway",
+			},
+			"ClusterLocal": {
+				"class":   "istio",
+				"gateway": "istio-system/knative-local-gateway",
+				"service": "istio-system/knative-local-gateway",
+			},
+		},
+	}, {
+		name: "Invalid Gateway",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+						LocalGateway: &servingv1alpha1.GatewayReference{
+							Gateway: "knative-local",
+						},
+					},
+				},
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "Invalid Service",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+						ExternalGateway: &servingv1alpha1.GatewayReference{
+							Gateway: "gateways/external",
+							Service: "gateways/envoy/external",
+						},
+					},
+				},
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.22/net-gateway-api.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+
+			manifest, err = manifest.Transform(configureGatewayReferences(tt.instance))
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+			configMap := manifest.Filter(mf.ByKind("ConfigMap"), mf.ByName("config-gateway")).Resources()[0]
+			data, _, _ := unstructured.NestedStringMap(configMap.Object, "data")
+			visibility := map[string]map[string]string{}
+			if err := yaml.Unmarshal([]byte(data[visibilityKey]), &visibility); err != nil {
+				t.Fatalf("Failed to parse the visibility: %v", err)
+			}
+			util.AssertDeepEqual(t, visibility, tt.expected)
+		})
+	}
+}
@@ -18,18 +18,30 @@ package ingress
 
 import (
 	"context"
-	"os"
-	"path/filepath"
-	"strings"
+	"fmt"
 
 	mf "github.com/manifestival/manifestival"
-	"golang.org/x/mod/semver"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	"knative.dev/operator/pkg/reconciler/common"
+	"sigs.k8s.io/yaml"
 )
 
-const providerLabel = "networking.knative.dev/ingress-provider"
+const (
+	providerLabel = "networking.knative.dev/ingress-provider"
+	visibilityKey = "visibility"
+
+	networkConfigMapName = "config-network"
+	ingressClassKey      = "ingress.class"
+)
+
+// ingressClasses maps the names of the ingresses in the spec to their ingress classes.
+var ingressClasses = map[string]string{
+	"istio":      "istio.ingress.networking.knative.dev",
+	"kourier":    "kourier.ingress.networking.knative.dev",
+	"contour":    "contour.ingress.networking.knative.dev",
+	"gatewayAPI": "gateway-api.ingress.networking.knative.dev",
+}
 
 func ingressFilter(name string) mf.Predicate {
 	return func(u *unstructured.Unstructured) bool {
@@ -47,20 +59,56 @@ func noneFilter(u *unstructured.Unstructured) bool {
 	return !hasLabel
 }
 
-// Filters makes sure the disabled ingress resources are removed from the manifest.
-func Filters(ks *v1alpha1.KnativeServing) mf.Predicate {
-	var filters []mf.Predicate
-	if ks.Spec.Ingress == nil {
-		return istioFilter
+// ingressFilters maps the names of the ingresses in the spec to their filters.
+var ingressFilters = map[string]mf.Predicate{
+	"istio":      istioFilter,
+	"kourier":    kourierFilter,
+	"contour":    contourFilter,
+	"gatewayAPI": gatewayAPIFilter,
+}
+
+// ingressDependencies maps the names of the ingresses in the spec to the resources they require.
+// Kourier ships its own gateway, and has no dependencies.
+var ingressDependencies = map[string][]common.Dependency{
+	"istio":      istioDependencies,
+	"contour":    contourDependencies,
+	"gatewayAPI": gatewayAPIDependencies,
+}
+
+// Dependencies returns the resources required by the enabled ingresses.
+func Dependencies(instance v1alpha1.KComponent) []common.Dependency {
+	ks, ok := instance.(*v1alpha1.KnativeServing)
+	if !ok {
+		return nil
 	}
-	if ks.Spec.Ingress.Istio.Enabled {
-		filters = append(filters, istioFilter)
+	var deps []common.Dependency
+	for _, name := range ks.Spec.EnabledIngresses() {
+		deps = append(deps, ingressDependencies[name]...)
 	}
-	if ks.Spec.Ingress.Kourier.Enabled {
-		filters = append(filters, kourierFilter)
+	return deps
+}
+
+// Filters makes sure the disabled ingress resources are removed from the manifest.
+func Filters(ks *v1alpha1.KnativeServing) mf.Predicate {
+	return filtersFor(ks.Spec.EnabledIngresses())
+}
+
+// InstalledFilters makes sure the resources of the ingresses, which were not enabled for the
+// installed release, are removed from the manifest. The ingresses enabled in the spec are used
+// if the status does not record them.
+func InstalledFilters(ks *v1alpha1.KnativeServing) mf.Predicate {
+	if ks.Status.Ingresses == nil {
+		return Filters(ks)
 	}
-	if ks.Spec.Ingress.Contour.Enabled {
-		filters = append(filters, contourFilter)
+	return filtersFor(ks.Status.Ingresses)
+}
+
+func filtersFor(names []string) mf.Predicate {
+	var filters []mf.Predicate
+	for _, name := range names {
+		if filter, ok := ingressFilters[name]; ok {
+			filters = append(filters, filter)
+		}
 	}
 	if len(filters) == 0 {
 		return noneFilter
@@ -70,10 +118,10 @@ func Filters(ks *v1alpha1.KnativeServing) mf.Predicate {
 
 // Transformers returns a list of transformers based on the enabled ingresses
 func Transformers(ctx context.Context, ks *v1alpha1.KnativeServing) []mf.Transformer {
+	transformers := []mf.Transformer{ingressClassTransform(ks)}
 	if ks.Spec.Ingress == nil {
-		return istioTransformers(ctx, ks)
+		return append(transformers, istioTransformers(ctx, ks)...)
 	}
-	var transformers []mf.Transformer
 	if ks.Spec.Ingress.Istio.Enabled {
 		transformers = append(transformers, istioTransformers(ctx, ks)...)
 	}
@@ -83,44 +131,149 @@ func Transformers(ctx context.Context, ks *v1alpha1.KnativeServing) []mf.Transfo
 	if ks.Spec.Ingress.Contour.Enabled {
 		transformers = append(transformers, contourTransformers(ctx, ks)...)
 	}
+	if ks.Spec.Ingress.GatewayAPI.Enabled {
+		transformers = append(transformers, gatewayAPITransformers(ctx, ks)...)
+	}
 	return transformers
 }
 
-func getIngress(version string, manifest *mf.Manifest) error {
+// InstalledTransformers returns a list of transformers, which move the resources of the installed
+// manifest to the namespaces recorded in the status.
+func InstalledTransformers(ks *v1alpha1.KnativeServing) []mf.Transformer {
+	return installedKourierTransformers(ks)
+}
+
+// ingressClassTransform sets the ingress.class of the config-network ConfigMap to the class of the
+// default ingress, unless it is set in the spec.
+func ingressClassTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != networkConfigMapName || hasIngressClassConfig(ks) {
+			return nil
+		}
+		name, err := defaultIngress(ks)
+		if err != nil || name == "" {
+			return err
+		}
+		return unstructured.SetNestedField(u.Object, ingressClasses[name], "data", ingressClassKey)
+	}
+}
+
+// hasIngressClassConfig returns whether the ingress.class is set in the spec.
+func hasIngressClassConfig(ks *v1alpha1.KnativeServing) bool {
+	for _, name := range []string{networkConfigMapName, "network"} {
+		if _, ok := ks.Spec.Config[name][ingressClassKey]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultIngress returns the name of the default ingress, i.e. spec.ingress.default or the first
+// enabled of istio, kourier, contour and gatewayAPI. It is empty if no ingress is enabled.
+func defaultIngress(ks *v1alpha1.KnativeServing) (string, error) {
+	enabled := ks.Spec.EnabledIngresses()
+	if ks.Spec.Ingress != nil && ks.Spec.Ingress.Default != "" {
+		name := ks.Spec.Ingress.Default
+		if _, ok := ingressClasses[name]; !ok {
+			return "", fmt.Errorf("unknown default ingress %q", name)
+		}
+		for _, e := range enabled {
+			if e == name {
+				return name, nil
+			}
+		}
+		return "", fmt.Errorf("the default ingress %q is not enabled", name)
+	}
+	if len(enabled) == 0 {
+		return "", nil
+	}
+	return enabled[0], nil
+}
+
+// getIngress appends the manifest of the ingresses matching the version of Knative Serving, with the
+// resources of the ingresses with pinned releases replaced by the ones of the releases fetched with
+// fetch.
+func getIngress(ctx context.Context, instance v1alpha1.KComponent, version string,
+	releases map[string]v1alpha1.IngressRelease, fetch manifestFetcher, manifest *mf.Manifest) error {
 	// If we can not determine the version, append no ingress manifest.
 	if version == "" {
 		return nil
 	}
-	koDataDir := os.Getenv(common.KoEnvKey)
-	// Ingresses are saved in the directory named major.minor. We remove the patch number.
-	ingressVersion := common.LATEST_VERSION
-	if !strings.EqualFold(version, common.LATEST_VERSION) {
-		ingressVersion = semver.MajorMinor(common.SanitizeSemver(version))[1:]
+	// Ingresses are saved in the directories named after the patch or the major.minor version.
+	ingressPath, err := common.AlternativeManifests(common.IngressCatalog, version, "")
+	if err != nil {
+		return err
 	}
-
-	// This line can make sure a valid available ingress version is returned.
-	ingressVersion = common.GetLatestIngressRelease(ingressVersion)
-	ingressPath := filepath.Join(koDataDir, "ingress", ingressVersion)
-	m, err := common.FetchManifest(ingressPath)
+	m, err := common.FetchManifest(ctx, instance, ingressPath)
 	if err != nil {
 		return err
 	}
+	if ks, ok := instance.(*v1alpha1.KnativeServing); ok {
+		if m, err = appendIngressReleases(ctx, ks, releases, fetch, version, m); err != nil {
+			return err
+		}
+	}
 	*manifest = manifest.Append(m)
 	return nil
 }
 
-// AppendTargetIngresses appends the manifests of ingresses to be installed
+// AppendTargetIngresses appends the manifests of ingresses to be installed. Every enabled ingress
+// has to have resources for the target version.
 func AppendTargetIngresses(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
-	return getIngress(common.TargetVersion(instance), manifest)
+	version := common.TargetVersion(instance)
+	ks, isServing := instance.(*v1alpha1.KnativeServing)
+	if isServing {
+		if err := validateIngressReleases(ks, version); err != nil {
+			return err
+		}
+	}
+	m, err := mf.ManifestFrom(mf.Slice{})
+	if err != nil {
+		return err
+	}
+	var releases map[string]v1alpha1.IngressRelease
+	if isServing {
+		releases = ks.Spec.IngressReleases()
+	}
+	// The pinned releases are fetched without the cache, like spec.manifests, so that the changes to
+	// their content are picked up.
+	if err := getIngress(ctx, instance, version, releases, common.FetchManifestFromPath, &m); err != nil {
+		return err
+	}
+	if isServing && version != "" {
+		if err := checkIngressResources(ks, version, m); err != nil {
+			instance.GetStatus().MarkInstallFailed(err.Error())
+			return err
+		}
+	}
+	*manifest = manifest.Append(m)
+	return nil
+}
+
+// checkIngressResources makes sure that every enabled ingress has resources in the manifest, so
+// that an ingress, which is not released for the version, is not set as the ingress.class while
+// no controller of it is installed.
+func checkIngressResources(ks *v1alpha1.KnativeServing, version string, m mf.Manifest) error {
+	for _, name := range ks.Spec.EnabledIngresses() {
+		if len(m.Filter(providerFilter(name)).Resources()) == 0 {
+			return fmt.Errorf("the ingress %s is not available for the version %s of Knative Serving", name, version)
+		}
+	}
+	return nil
 }
 
-// AppendInstalledIngresses appends the installed manifests of ingresses
+// AppendInstalledIngresses appends the installed manifests of ingresses, with the releases pinned
+// for the installed release.
 func AppendInstalledIngresses(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
 	version := instance.GetStatus().GetVersion()
 	if version == "" {
 		version = common.TargetVersion(instance)
 	}
-	return getIngress(version, manifest)
+	var releases map[string]v1alpha1.IngressRelease
+	if ks, ok := instance.(*v1alpha1.KnativeServing); ok {
+		releases = installedIngressReleases(ks)
+	}
+	return getIngress(ctx, instance, version, releases, common.FetchManifest, manifest)
 }
 
 func hasProviderLabel(u *unstructured.Unstructured) bool {
@@ -129,3 +282,32 @@ func hasProviderLabel(u *unstructured.Unstructured) bool {
 	}
 	return false
 }
+
+// updateVisibility updates the settings per visibility, e.g. ExternalIP and ClusterLocal, in the
+// visibility key of the ConfigMap of an ingress. The defaults are used if the key is not set.
+func updateVisibility(u *unstructured.Unstructured, defaults string, update func(map[string]map[string]string) error) error {
+	data, _, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		data = map[string]string{}
+	}
+	current, ok := data[visibilityKey]
+	if !ok {
+		current = defaults
+	}
+	visibility := map[string]map[string]string{}
+	if err := yaml.Unmarshal([]byte(current), &visibility); err != nil {
+		return fmt.Errorf("failed to parse the %s of %s: %w", visibilityKey, u.GetName(), err)
+	}
+	if err := update(visibility); err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(visibility)
+	if err != nil {
+		return err
+	}
+	data[visibilityKey] = string(out)
+	return unstructured.SetNestedStringMap(u.Object, data, "data")
+}
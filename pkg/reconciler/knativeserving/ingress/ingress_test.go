@@ -49,7 +49,7 @@ func TestGetIngress(t *testing.T) {
 	}, {
 		name:        "Unavailable ingresses",
 		version:     "0.16.1",
-		expectedErr: fmt.Errorf("stat testdata/kodata/ingress/0.16: no such file or directory"),
+		expectedErr: fmt.Errorf("the version 0.16 of ingress is not available in the catalog"),
 	}, {
 		name:                "Missing version",
 		version:             "",
@@ -60,7 +60,7 @@ func TestGetIngress(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			manifest, _ := mf.ManifestFrom(mf.Slice{})
-			err := getIngress(tt.version, &manifest)
+			err := getIngress(context.TODO(), &servingv1alpha1.KnativeServing{}, tt.version, nil, common.FetchManifest, &manifest)
 			if err != nil {
 				util.AssertEqual(t, err.Error(), tt.expectedErr.Error())
 				util.AssertEqual(t, len(manifest.Resources()), 0)
@@ -111,7 +111,7 @@ func TestAppendInstalledIngresses(t *testing.T) {
 				Version: "0.12.1",
 			},
 		},
-		expectedErr: fmt.Errorf("stat testdata/kodata/ingress/0.12: no such file or directory"),
+		expectedErr: fmt.Errorf("the version 0.12 of ingress is not available in the catalog"),
 	}}
 
 	for _, tt := range tests {
@@ -158,7 +158,7 @@ func TestAppendTargetIngresses(t *testing.T) {
 				},
 			},
 		},
-		expectedErr: fmt.Errorf("stat testdata/kodata/ingress/0.12: no such file or directory"),
+		expectedErr: fmt.Errorf("the version 0.12 of ingress is not available in the catalog"),
 	}, {
 		name: "Get the latest target ingresses when the directory latest is unavailable",
 		instance: servingv1alpha1.KnativeServing{
@@ -170,6 +170,37 @@ func TestAppendTargetIngresses(t *testing.T) {
 		},
 		expectedIngressPath: os.Getenv(common.KoEnvKey) + "/ingress/0.22",
 		expectedErr:         nil,
+	}, {
+		name: "Enabled ingress released for the target version",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{
+					Version: "0.21.0",
+				},
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expectedIngressPath: os.Getenv(common.KoEnvKey) + "/ingress/0.21",
+		expectedErr:         nil,
+	}, {
+		name: "Enabled ingress not released for the target version",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{
+					Version: "0.20.0",
+				},
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expectedErr: fmt.Errorf("the ingress gatewayAPI is not available for the version 0.20.0 of Knative Serving"),
 	}}
 
 	for _, tt := range tests {
@@ -228,6 +259,22 @@ func TestGetIngressWithFilters(t *testing.T) {
 		},
 		expected:             true,
 		expectedManifestPath: os.Getenv(common.KoEnvKey) + "/ingress/" + version + "/net-contour.yaml",
+	}, {
+		name: "Enabled Gateway API ingress for target manifests",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{
+					Version: version,
+				},
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected:             true,
+		expectedManifestPath: os.Getenv(common.KoEnvKey) + "/ingress/" + version + "/net-gateway-api.yaml",
 	}, {
 		name: "Enabled Kourier ingress for target manifests",
 		instance: servingv1alpha1.KnativeServing{
@@ -321,6 +368,9 @@ func TestGetIngressWithFilters(t *testing.T) {
 					Kourier: servingv1alpha1.KourierIngressConfiguration{
 						Enabled: true,
 					},
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
 				},
 			},
 		},
@@ -330,10 +380,10 @@ func TestGetIngressWithFilters(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			targetIngressManifests, err := common.FetchManifest(tt.expectedManifestPath)
+			targetIngressManifests, err := common.FetchManifest(context.TODO(), &tt.instance, tt.expectedManifestPath)
 			util.AssertEqual(t, err, nil)
 			manifest, _ := mf.ManifestFrom(mf.Slice{})
-			err = getIngress(version, &manifest)
+			err = getIngress(context.TODO(), &tt.instance, version, tt.instance.Spec.IngressReleases(), common.FetchManifest, &manifest)
 			util.AssertEqual(t, err == nil, tt.expected)
 			manifest = manifest.Filter(Filters(&tt.instance))
 			// The resources loaded with the enabled istio ingress returns exactly the same resources as we
@@ -440,6 +490,19 @@ func TestFilters(t *testing.T) {
 		},
 		labels:   []string{"istio", "contour", "kourier", ""},
 		expected: []bool{false, true, false, true},
+	}, {
+		name: "Enabled Gateway API ingress for all resources",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		labels:   []string{"istio", "contour", "kourier", "net-gateway-api", ""},
+		expected: []bool{false, false, false, true, true},
 	}, {
 		name: "Enabled Contour and Istio ingress for all resources",
 		instance: servingv1alpha1.KnativeServing{
@@ -502,11 +565,14 @@ func TestFilters(t *testing.T) {
 					Contour: servingv1alpha1.ContourIngressConfiguration{
 						Enabled: true,
 					},
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
 				},
 			},
 		},
-		labels:   []string{"istio", "contour", "kourier", ""},
-		expected: []bool{true, true, true, true},
+		labels:   []string{"istio", "contour", "kourier", "net-gateway-api", ""},
+		expected: []bool{true, true, true, true, true},
 	}, {
 		name: "Disabled All ingress",
 		instance: servingv1alpha1.KnativeServing{
@@ -521,11 +587,14 @@ func TestFilters(t *testing.T) {
 					Contour: servingv1alpha1.ContourIngressConfiguration{
 						Enabled: false,
 					},
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: false,
+					},
 				},
 			},
 		},
-		labels:   []string{"istio", "contour", "kourier", ""},
-		expected: []bool{false, false, false, true},
+		labels:   []string{"istio", "contour", "kourier", "net-gateway-api", ""},
+		expected: []bool{false, false, false, false, true},
 	}}
 
 	for _, tt := range tests {
@@ -539,6 +608,99 @@ func TestFilters(t *testing.T) {
 	}
 }
 
+func TestInstalledFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance servingv1alpha1.KnativeServing
+		labels   []string
+		expected []bool
+	}{{
+		name: "Ingresses recorded in the status",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Kourier: servingv1alpha1.KourierIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+			Status: servingv1alpha1.KnativeServingStatus{
+				Ingresses: []string{"istio", "contour"},
+			},
+		},
+		labels:   []string{"istio", "contour", "kourier", ""},
+		expected: []bool{true, true, false, true},
+	}, {
+		name: "Ingresses not recorded in the status",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Kourier: servingv1alpha1.KourierIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		labels:   []string{"istio", "contour", "kourier", ""},
+		expected: []bool{false, false, true, true},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, label := range tt.labels {
+				ingressResource := makeIngressResource(t, "test-service", "knative-serving", label)
+				result := InstalledFilters(&tt.instance)(ingressResource)
+				util.AssertEqual(t, result, tt.expected[i])
+			}
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	tests := []struct {
+		name     string
+		instance servingv1alpha1.KComponent
+		expected []string
+	}{{
+		name:     "Default istio ingress",
+		instance: &servingv1alpha1.KnativeServing{},
+		expected: []string{"Gateway", "VirtualService"},
+	}, {
+		name: "Kourier ingress without dependencies",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+	}, {
+		name: "Contour and Gateway API ingresses",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Contour:    servingv1alpha1.ContourIngressConfiguration{Enabled: true},
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+		expected: []string{"HTTPProxy", "Gateway", "HTTPRoute"},
+	}, {
+		name:     "Knative Eventing",
+		instance: &servingv1alpha1.KnativeEventing{},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kinds []string
+			for _, dep := range Dependencies(tt.instance) {
+				kinds = append(kinds, dep.Kind)
+			}
+			util.AssertDeepEqual(t, kinds, tt.expected)
+		})
+	}
+}
+
 // TODO: This test verifies the number of transformers. It should be rewritten by better test.
 func TestTransformers(t *testing.T) {
 	tests := []struct {
@@ -556,7 +718,7 @@ func TestTransformers(t *testing.T) {
 				},
 			},
 		},
-		expected: 1,
+		expected: 4,
 	}, {
 		name: "Available kourier ingress",
 		instance: servingv1alpha1.KnativeServing{
@@ -568,7 +730,7 @@ func TestTransformers(t *testing.T) {
 				},
 			},
 		},
-		expected: 2,
+		expected: 5,
 	}, {
 		name: "Available contour ingress",
 		instance: servingv1alpha1.KnativeServing{
@@ -580,13 +742,25 @@ func TestTransformers(t *testing.T) {
 				},
 			},
 		},
-		expected: 0,
+		expected: 3,
+	}, {
+		name: "Available Gateway API ingress",
+		instance: servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
+				},
+			},
+		},
+		expected: 2,
 	}, {
 		name: "Empty ingress for default istio",
 		instance: servingv1alpha1.KnativeServing{
 			Spec: servingv1alpha1.KnativeServingSpec{},
 		},
-		expected: 1,
+		expected: 4,
 	}, {
 		name: "All ingresses enabled",
 		instance: servingv1alpha1.KnativeServing{
@@ -601,10 +775,13 @@ func TestTransformers(t *testing.T) {
 					Istio: servingv1alpha1.IstioIngressConfiguration{
 						Enabled: true,
 					},
+					GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{
+						Enabled: true,
+					},
 				},
 			},
 		},
-		expected: 3,
+		expected: 11,
 	}}
 
 	for _, tt := range tests {
@@ -615,6 +792,103 @@ func TestTransformers(t *testing.T) {
 	}
 }
 
+func TestIngressClassTransform(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      servingv1alpha1.KnativeServingSpec
+		expected  string
+		expectErr bool
+	}{{
+		name:     "Default istio ingress",
+		spec:     servingv1alpha1.KnativeServingSpec{},
+		expected: "istio.ingress.networking.knative.dev",
+	}, {
+		name: "Enabled kourier ingress",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+			},
+		},
+		expected: "kourier.ingress.networking.knative.dev",
+	}, {
+		name: "First of several enabled ingresses",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Contour:    servingv1alpha1.ContourIngressConfiguration{Enabled: true},
+				GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{Enabled: true},
+			},
+		},
+		expected: "contour.ingress.networking.knative.dev",
+	}, {
+		name: "Default of several enabled ingresses",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Istio:      servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+				GatewayAPI: servingv1alpha1.GatewayAPIIngressConfiguration{Enabled: true},
+				Default:    "gatewayAPI",
+			},
+		},
+		expected: "gateway-api.ingress.networking.knative.dev",
+	}, {
+		name: "Ingress class set in the spec",
+		spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{
+				Config: servingv1alpha1.ConfigMapData{
+					"network": {"ingress.class": "custom.ingress.networking.knative.dev"},
+				},
+			},
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+			},
+		},
+		expected: "istio.ingress.networking.knative.dev",
+	}, {
+		name: "No enabled ingress",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{},
+		},
+		expected: "istio.ingress.networking.knative.dev",
+	}, {
+		name: "Disabled default ingress",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+				Default: "contour",
+			},
+		},
+		expectErr: true,
+	}, {
+		name: "Unknown default ingress",
+		spec: servingv1alpha1.KnativeServingSpec{
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+				Default: "ambassador",
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &unstructured.Unstructured{}
+			cm.SetAPIVersion("v1")
+			cm.SetKind("ConfigMap")
+			cm.SetName("config-network")
+			unstructured.SetNestedStringMap(cm.Object, map[string]string{
+				"ingress.class": "istio.ingress.networking.knative.dev",
+			}, "data")
+
+			err := ingressClassTransform(&servingv1alpha1.KnativeServing{Spec: tt.spec})(cm)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+			class, _, _ := unstructured.NestedString(cm.Object, "data", "ingress.class")
+			util.AssertEqual(t, class, tt.expected)
+		})
+	}
+}
+
 func makeIngressResource(t *testing.T, name, ns, ingressLabel string) *unstructured.Unstructured {
 	labels := map[string]string{}
 	if ingressLabel != "" {
@@ -18,20 +18,47 @@ package ingress
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	mf "github.com/manifestival/manifestival"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
 	"knative.dev/pkg/logging"
 )
 
-var istioFilter = ingressFilter("istio")
+const (
+	istioConfigMapName   = "config-istio"
+	localGatewayName     = "knative-local-gateway"
+	ingressGatewayPrefix = "gateway."
+	localGatewayPrefix   = "local-gateway."
+	istioRevisionLabel   = "istio.io/rev"
+)
+
+var (
+	istioFilter = ingressFilter("istio")
+
+	istioDependencies = []common.Dependency{
+		{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "Gateway"},
+		{Name: "Istio", Groups: []string{"networking.istio.io"}, Kind: "VirtualService"},
+	}
+
+	istioProtocols = sets.NewString("HTTP", "HTTPS", "GRPC", "HTTP2", "MONGO", "TCP", "TLS")
+	istioTLSModes  = sets.NewString("PASSTHROUGH", "SIMPLE", "MUTUAL", "AUTO_PASSTHROUGH", "ISTIO_MUTUAL")
+)
 
 func istioTransformers(ctx context.Context, instance *v1alpha1.KnativeServing) []mf.Transformer {
 	logger := logging.FromContext(ctx)
-	return []mf.Transformer{gatewayTransform(instance, logger)}
+	return []mf.Transformer{
+		revisionSelectorTransform(instance),
+		gatewayTransform(instance, logger),
+		configureIstioGateways(instance),
+	}
 }
 
 func gatewayTransform(instance *servingv1alpha1.KnativeServing, log *zap.SugaredLogger) mf.Transformer {
@@ -51,6 +78,72 @@ func gatewayTransform(instance *servingv1alpha1.KnativeServing, log *zap.Sugared
 	}
 }
 
+// revisionSelectorTransform adds the revision of the spec to the selectors of the Gateways and of the
+// knative-local-gateway Service, so that they target the gateway pods of that Istio revision. The
+// selectors of the gateway overrides, which are applied later, replace the ones of the Gateways.
+func revisionSelectorTransform(instance *servingv1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if instance.Spec.Ingress == nil || instance.Spec.Ingress.Istio.Revision == "" || !hasProviderLabel(u) {
+			return nil
+		}
+		isGateway := u.GetKind() == "Gateway" && (u.GetName() == "knative-ingress-gateway" || u.GetName() == localGatewayName)
+		isService := u.GetKind() == "Service" && u.GetName() == localGatewayName
+		if !isGateway && !isService {
+			return nil
+		}
+		selector, _, err := unstructured.NestedStringMap(u.Object, "spec", "selector")
+		if err != nil {
+			return err
+		}
+		if selector == nil {
+			selector = map[string]string{}
+		}
+		selector[istioRevisionLabel] = instance.Spec.Ingress.Istio.Revision
+		return unstructured.SetNestedStringMap(u.Object, selector, "spec", "selector")
+	}
+}
+
+// configureIstioGateways points the gateway entries of the config-istio ConfigMap at the Services
+// in the gateway namespaces of the spec, unless gateway entries are set in the spec.config.
+func configureIstioGateways(instance *servingv1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != istioConfigMapName || !hasProviderLabel(u) {
+			return nil
+		}
+		if instance.Spec.Ingress == nil {
+			return nil
+		}
+		config := instance.Spec.Ingress.Istio
+		// The entries are in the format of {prefix}{gateway_namespace}.{gateway_name}: {service}.{namespace}.svc.cluster.local.
+		if ns := config.IngressGatewayNamespace; ns != "" && !hasIstioConfig(instance, ingressGatewayPrefix) {
+			key := ingressGatewayPrefix + instance.GetNamespace() + ".knative-ingress-gateway"
+			if err := unstructured.SetNestedField(u.Object, "istio-ingressgateway."+ns+".svc.cluster.local", "data", key); err != nil {
+				return err
+			}
+		}
+		if ns := config.LocalGatewayNamespace; ns != "" && !hasIstioConfig(instance, localGatewayPrefix) {
+			key := localGatewayPrefix + instance.GetNamespace() + "." + localGatewayName
+			if err := unstructured.SetNestedField(u.Object, localGatewayName+"."+ns+".svc.cluster.local", "data", key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// hasIstioConfig returns whether an entry of the config-istio ConfigMap starting with prefix is set
+// in the spec.
+func hasIstioConfig(instance *servingv1alpha1.KnativeServing, prefix string) bool {
+	for _, name := range []string{istioConfigMapName, "istio"} {
+		for key := range instance.Spec.Config[name] {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func ingressGateway(instance *servingv1alpha1.KnativeServing) *servingv1alpha1.IstioGatewayOverride {
 	if instance.Spec.Ingress != nil && instance.Spec.Ingress.Istio.KnativeIngressGateway != nil {
 		return instance.Spec.Ingress.Istio.KnativeIngressGateway
@@ -66,10 +159,59 @@ func localGateway(instance *servingv1alpha1.KnativeServing) *servingv1alpha1.Ist
 }
 
 func updateIstioGateway(override *servingv1alpha1.IstioGatewayOverride, u *unstructured.Unstructured, log *zap.SugaredLogger) error {
-	if override != nil && len(override.Selector) > 0 {
-		log.Debugw("Updating Gateway", "name", u.GetName(), "gatewayOverrides", override)
+	if override == nil || (len(override.Selector) == 0 && len(override.Servers) == 0) {
+		return nil
+	}
+	log.Debugw("Updating Gateway", "name", u.GetName(), "gatewayOverrides", override)
+	if len(override.Selector) > 0 {
 		unstructured.SetNestedStringMap(u.Object, override.Selector, "spec", "selector")
-		log.Debugw("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
+	}
+	if len(override.Servers) > 0 {
+		servers := make([]interface{}, 0, len(override.Servers))
+		for i := range override.Servers {
+			server := &override.Servers[i]
+			if err := validateIstioServer(server); err != nil {
+				return fmt.Errorf("invalid server %d of %s: %w", i, u.GetName(), err)
+			}
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(server)
+			if err != nil {
+				return err
+			}
+			servers = append(servers, obj)
+		}
+		if err := unstructured.SetNestedSlice(u.Object, servers, "spec", "servers"); err != nil {
+			return err
+		}
+	}
+	log.Debugw("Finished conversion", "name", u.GetName(), "unstructured", u.Object)
+	return nil
+}
+
+func validateIstioServer(server *servingv1alpha1.IstioGatewayServer) error {
+	if server.Port.Number <= 0 || server.Port.Name == "" {
+		return fmt.Errorf("the port requires a number and a name")
+	}
+	if !istioProtocols.Has(server.Port.Protocol) {
+		return fmt.Errorf("unknown protocol %q", server.Port.Protocol)
+	}
+	if len(server.Hosts) == 0 {
+		return fmt.Errorf("at least one host is required")
+	}
+	if server.TLS == nil {
+		if server.Port.Protocol == "HTTPS" {
+			return fmt.Errorf("the protocol HTTPS requires the tls settings")
+		}
+		return nil
+	}
+	if server.TLS.Mode == "" {
+		// Istio defaults to PASSTHROUGH, which would silently leave the TLS to the backends.
+		if server.Port.Protocol == "HTTPS" || server.TLS.CredentialName != "" {
+			return fmt.Errorf("the tls mode is required to terminate the TLS, e.g. SIMPLE")
+		}
+		return nil
+	}
+	if !istioTLSModes.Has(server.TLS.Mode) {
+		return fmt.Errorf("unknown tls mode %q", server.TLS.Mode)
 	}
 	return nil
 }
@@ -20,7 +20,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	mf "github.com/manifestival/manifestival"
 	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	util "knative.dev/operator/pkg/reconciler/common/testing"
@@ -155,6 +157,300 @@ func TestGatewayTransform(t *testing.T) {
 	}
 }
 
+func TestGatewayTransformServers(t *testing.T) {
+	httpsServer := servingv1alpha1.IstioGatewayServer{
+		Port:  servingv1alpha1.IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+		Hosts: []string{"*.example.com"},
+		TLS: &servingv1alpha1.IstioGatewayTLS{
+			Mode:           "SIMPLE",
+			CredentialName: "wildcard-certs",
+		},
+	}
+	httpServer := servingv1alpha1.IstioGatewayServer{
+		Port:  servingv1alpha1.IstioGatewayPort{Number: 80, Name: "http", Protocol: "HTTP"},
+		Hosts: []string{"*"},
+		TLS:   &servingv1alpha1.IstioGatewayTLS{HTTPSRedirect: true},
+	}
+
+	tests := []struct {
+		name        string
+		gatewayName string
+		override    *servingv1alpha1.IstioGatewayOverride
+		expected    []interface{}
+		expectErr   bool
+	}{{
+		name:        "keep the servers without override",
+		gatewayName: "knative-ingress-gateway",
+		override:    gatewayOverride(map[string]string{"istio": "knative-ingress"}),
+		expected: []interface{}{map[string]interface{}{
+			"port":  map[string]interface{}{"number": int64(80), "name": "http", "protocol": "HTTP"},
+			"hosts": []interface{}{"*"},
+		}},
+	}, {
+		name:        "replace the servers of the ingress gateway",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{httpServer, httpsServer},
+		},
+		expected: []interface{}{map[string]interface{}{
+			"port":  map[string]interface{}{"number": int64(80), "name": "http", "protocol": "HTTP"},
+			"hosts": []interface{}{"*"},
+			"tls":   map[string]interface{}{"httpsRedirect": true},
+		}, map[string]interface{}{
+			"port":  map[string]interface{}{"number": int64(443), "name": "https", "protocol": "HTTPS"},
+			"hosts": []interface{}{"*.example.com"},
+			"tls":   map[string]interface{}{"mode": "SIMPLE", "credentialName": "wildcard-certs"},
+		}},
+	}, {
+		name:        "reject HTTPS without tls settings",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{{
+				Port:  servingv1alpha1.IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+				Hosts: []string{"*"},
+			}},
+		},
+		expectErr: true,
+	}, {
+		name:        "reject unknown tls mode",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{{
+				Port:  servingv1alpha1.IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+				Hosts: []string{"*"},
+				TLS:   &servingv1alpha1.IstioGatewayTLS{Mode: "STRICT"},
+			}},
+		},
+		expectErr: true,
+	}, {
+		name:        "reject HTTPS without tls mode",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{{
+				Port:  servingv1alpha1.IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+				Hosts: []string{"*"},
+				TLS:   &servingv1alpha1.IstioGatewayTLS{CredentialName: "wildcard-certs"},
+			}},
+		},
+		expectErr: true,
+	}, {
+		name:        "reject credentialName without tls mode",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{{
+				Port:  servingv1alpha1.IstioGatewayPort{Number: 443, Name: "tls", Protocol: "TLS"},
+				Hosts: []string{"*"},
+				TLS:   &servingv1alpha1.IstioGatewayTLS{CredentialName: "wildcard-certs"},
+			}},
+		},
+		expectErr: true,
+	}, {
+		name:        "reject server without hosts",
+		gatewayName: "knative-ingress-gateway",
+		override: &servingv1alpha1.IstioGatewayOverride{
+			Servers: []servingv1alpha1.IstioGatewayServer{{
+				Port: servingv1alpha1.IstioGatewayPort{Number: 80, Name: "http", Protocol: "HTTP"},
+			}},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gateway := makeUnstructuredGateway(t, tt.gatewayName, map[string]string{"istio": "old-istio"})
+			unstructured.SetNestedSlice(gateway.Object, []interface{}{map[string]interface{}{
+				"port":  map[string]interface{}{"number": int64(80), "name": "http", "protocol": "HTTP"},
+				"hosts": []interface{}{"*"},
+			}}, "spec", "servers")
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					Ingress: &servingv1alpha1.IngressConfigs{
+						Istio: servingv1alpha1.IstioIngressConfiguration{
+							Enabled:               true,
+							KnativeIngressGateway: tt.override,
+						},
+					},
+				},
+			}
+
+			err := gatewayTransform(instance, log)(gateway)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+
+			got, _, err := unstructured.NestedSlice(gateway.Object, "spec", "servers")
+			util.AssertEqual(t, err, nil)
+			if !cmp.Equal(got, tt.expected) {
+				t.Errorf("Got = %v, want: %v, diff:\n%s", got, tt.expected, cmp.Diff(got, tt.expected))
+			}
+		})
+	}
+}
+
+func TestConfigureIstioGateways(t *testing.T) {
+	tests := []struct {
+		name      string
+		istio     servingv1alpha1.IstioIngressConfiguration
+		config    map[string]map[string]string
+		dropLabel bool
+		expected  map[string]string
+	}{{
+		name:     "Keep the default gateways",
+		istio:    servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+		expected: map[string]string{},
+	}, {
+		name: "Set both gateway namespaces",
+		istio: servingv1alpha1.IstioIngressConfiguration{
+			Enabled:                 true,
+			IngressGatewayNamespace: "istio-ingress",
+			LocalGatewayNamespace:   "istio-local",
+		},
+		expected: map[string]string{
+			"gateway.serving.knative-ingress-gateway":     "istio-ingressgateway.istio-ingress.svc.cluster.local",
+			"local-gateway.serving.knative-local-gateway": "knative-local-gateway.istio-local.svc.cluster.local",
+		},
+	}, {
+		name: "Keep the gateways set in the spec.config",
+		istio: servingv1alpha1.IstioIngressConfiguration{
+			Enabled:                 true,
+			IngressGatewayNamespace: "istio-ingress",
+			LocalGatewayNamespace:   "istio-local",
+		},
+		config: map[string]map[string]string{
+			"istio": {"local-gateway.mesh": "mesh"},
+		},
+		expected: map[string]string{
+			"gateway.serving.knative-ingress-gateway": "istio-ingressgateway.istio-ingress.svc.cluster.local",
+		},
+	}, {
+		name: "Do not transform without the ingress provider label",
+		istio: servingv1alpha1.IstioIngressConfiguration{
+			Enabled:                 true,
+			IngressGatewayNamespace: "istio-ingress",
+			LocalGatewayNamespace:   "istio-local",
+		},
+		dropLabel: true,
+		expected:  map[string]string{},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "serving"},
+				Spec: servingv1alpha1.KnativeServingSpec{
+					CommonSpec: servingv1alpha1.CommonSpec{Config: tt.config},
+					Ingress:    &servingv1alpha1.IngressConfigs{Istio: tt.istio},
+				},
+			}
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.22/net-istio.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+			manifest, err = manifest.Transform(configureIstioGateways(instance))
+			if err != nil {
+				t.Fatalf("Failed to transform manifest: %v", err)
+			}
+
+			for _, u := range manifest.Filter(mf.ByKind("ConfigMap"), mf.ByName(istioConfigMapName)).Resources() {
+				data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+				got := map[string]string{}
+				for key, value := range data {
+					if key != "_example" {
+						got[key] = value
+					}
+				}
+				util.AssertDeepEqual(t, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRevisionSelectorTransform(t *testing.T) {
+	tests := []struct {
+		name        string
+		istio       servingv1alpha1.IstioIngressConfiguration
+		dropLabel   bool
+		expGateway  map[string]string
+		expLocal    map[string]string
+		expLocalSvc map[string]string
+	}{{
+		name:        "Keep the default selectors",
+		istio:       servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+		expGateway:  map[string]string{"istio": "ingressgateway"},
+		expLocal:    map[string]string{"istio": "ingressgateway"},
+		expLocalSvc: map[string]string{"istio": "ingressgateway"},
+	}, {
+		name:        "Select the gateways of the revision",
+		istio:       servingv1alpha1.IstioIngressConfiguration{Enabled: true, Revision: "1-10"},
+		expGateway:  map[string]string{"istio": "ingressgateway", "istio.io/rev": "1-10"},
+		expLocal:    map[string]string{"istio": "ingressgateway", "istio.io/rev": "1-10"},
+		expLocalSvc: map[string]string{"istio": "ingressgateway", "istio.io/rev": "1-10"},
+	}, {
+		name: "Keep the selector of the gateway override",
+		istio: servingv1alpha1.IstioIngressConfiguration{
+			Enabled:             true,
+			Revision:            "1-10",
+			KnativeLocalGateway: gatewayOverride(map[string]string{"istio": "knative-local-gateway"}),
+		},
+		expGateway:  map[string]string{"istio": "ingressgateway", "istio.io/rev": "1-10"},
+		expLocal:    map[string]string{"istio": "knative-local-gateway"},
+		expLocalSvc: map[string]string{"istio": "ingressgateway", "istio.io/rev": "1-10"},
+	}, {
+		name:        "Do not transform without the ingress provider label",
+		istio:       servingv1alpha1.IstioIngressConfiguration{Enabled: true, Revision: "1-10"},
+		dropLabel:   true,
+		expGateway:  map[string]string{"istio": "ingressgateway"},
+		expLocal:    map[string]string{"istio": "ingressgateway"},
+		expLocalSvc: map[string]string{"istio": "ingressgateway"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: "serving"},
+				Spec: servingv1alpha1.KnativeServingSpec{
+					Ingress: &servingv1alpha1.IngressConfigs{Istio: tt.istio},
+				},
+			}
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.22/net-istio.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+			manifest, err = manifest.Transform(revisionSelectorTransform(instance), gatewayTransform(instance, log))
+			if err != nil {
+				t.Fatalf("Failed to transform manifest: %v", err)
+			}
+
+			for _, expected := range []struct {
+				kind, name string
+				selector   map[string]string
+			}{
+				{"Gateway", "knative-ingress-gateway", tt.expGateway},
+				{"Gateway", localGatewayName, tt.expLocal},
+				{"Service", localGatewayName, tt.expLocalSvc},
+			} {
+				resources := manifest.Filter(mf.ByKind(expected.kind), mf.ByName(expected.name)).Resources()
+				util.AssertEqual(t, len(resources), 1)
+				selector, _, _ := unstructured.NestedStringMap(resources[0].Object, "spec", "selector")
+				util.AssertDeepEqual(t, selector, expected.selector)
+			}
+		})
+	}
+}
+
 func makeUnstructuredGateway(t *testing.T, name string, selector map[string]string) *unstructured.Unstructured {
 	result := &unstructured.Unstructured{}
 	result.SetAPIVersion("networking.istio.io/v1alpha3")
@@ -19,6 +19,8 @@ package ingress
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	mf "github.com/manifestival/manifestival"
 	appsv1 "k8s.io/api/apps/v1"
@@ -27,27 +29,42 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	kourierGatewayNSEnvVarKey = "KOURIER_GATEWAY_NAMESPACE"
 	kourierGatewayServiceName = "kourier"
+	kourierBootstrapName      = "kourier-bootstrap"
+	kourierBootstrapKey       = "envoy-bootstrap.yaml"
+	kourierControlServiceName = "kourier-control"
 )
 
-var kourierControllerDeploymentNames = sets.NewString("3scale-kourier-control", "net-kourier-controller")
+var (
+	kourierControllerDeploymentNames = sets.NewString("3scale-kourier-control", "net-kourier-controller")
+
+	// kourierGatewayResources are the names of the resources of the kourier gateway by kind.
+	kourierGatewayResources = map[string]sets.String{
+		"ConfigMap":  sets.NewString(kourierBootstrapName),
+		"Deployment": sets.NewString("3scale-kourier-gateway"),
+		"Service":    sets.NewString(kourierGatewayServiceName, "kourier-internal"),
+	}
+)
 
 var kourierFilter = ingressFilter("kourier")
 
 func kourierTransformers(ctx context.Context, instance *v1alpha1.KnativeServing) []mf.Transformer {
 	return []mf.Transformer{
-		replaceGWNamespace(),
+		replaceGWNamespace(instance),
+		relocateGateway(instance.Spec.Ingress.Kourier.GatewayNamespace),
 		configureGWServiceType(instance),
+		configureBootstrap(instance),
 	}
 }
 
 // replaceGWNamespace replace the environment variable KOURIER_GATEWAY_NAMESPACE with the
-// namespace of the deployment its set on.
-func replaceGWNamespace() mf.Transformer {
+// gateway namespace of the spec, or the namespace of the deployment its set on.
+func replaceGWNamespace(instance *v1alpha1.KnativeServing) mf.Transformer {
 	return func(u *unstructured.Unstructured) error {
 		if u.GetKind() == "Deployment" && kourierControllerDeploymentNames.Has(u.GetName()) && hasProviderLabel(u) {
 			deployment := &appsv1.Deployment{}
@@ -61,6 +78,9 @@ func replaceGWNamespace() mf.Transformer {
 					envVar := &c.Env[j]
 					if envVar.Name == kourierGatewayNSEnvVarKey {
 						envVar.Value = deployment.GetNamespace()
+						if ns := instance.Spec.Ingress.Kourier.GatewayNamespace; ns != "" {
+							envVar.Value = ns
+						}
 					}
 				}
 			}
@@ -73,31 +93,176 @@ func replaceGWNamespace() mf.Transformer {
 	}
 }
 
+// relocateGateway moves the resources of the kourier gateway to the given namespace, unless it is
+// empty. The KnativeServing instance cannot own resources outside of its namespace, so they are not
+// garbage collected with it. They are deleted with the installed manifest instead, which is moved
+// to the gateway namespace recorded in the status by installedKourierTransformers.
+func relocateGateway(ns string) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if ns == "" || !kourierGatewayResources[u.GetKind()].Has(u.GetName()) || !hasProviderLabel(u) {
+			return nil
+		}
+		u.SetNamespace(ns)
+		u.SetOwnerReferences(nil)
+		return nil
+	}
+}
+
+// installedKourierTransformers moves the resources of the kourier gateway of the installed manifest
+// to the gateway namespace recorded in the status, or back to the namespace of the instance if the
+// gateway was not moved, so that the resources left in a previous gateway namespace are deleted.
+// The gateway namespace of the spec is used if nothing has been recorded yet.
+func installedKourierTransformers(instance *v1alpha1.KnativeServing) []mf.Transformer {
+	ns := instance.Status.KourierGatewayNamespace
+	if instance.Status.Ingresses == nil {
+		ns = instance.Spec.KourierGatewayNamespace()
+	}
+	if ns == "" {
+		ns = instance.GetNamespace()
+	}
+	return []mf.Transformer{relocateGateway(ns)}
+}
+
 // configureGWServiceType configures Kourier GW's service type such as ClusterIP, LoadBalancer and NodePort.
 func configureGWServiceType(instance *v1alpha1.KnativeServing) mf.Transformer {
 	return func(u *unstructured.Unstructured) error {
 		if u.GetKind() == "Service" && u.GetName() == kourierGatewayServiceName && hasProviderLabel(u) {
-			if instance.Spec.Ingress.Kourier.ServiceType == "" {
-				// Do nothing if ServiceType is not configured.
-				return nil
+			return setServiceType(u, instance.Spec.Ingress.Kourier.ServiceType)
+		}
+		return nil
+	}
+}
+
+// setServiceType sets the type of the Service to ClusterIP, LoadBalancer or NodePort.
+func setServiceType(u *unstructured.Unstructured, serviceType v1.ServiceType) error {
+	if serviceType == "" {
+		// Do nothing if ServiceType is not configured.
+		return nil
+	}
+	svc := &v1.Service{}
+	if err := scheme.Scheme.Convert(u, svc, nil); err != nil {
+		return err
+	}
+
+	switch serviceType {
+	case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+		svc.Spec.Type = serviceType
+	default:
+		return fmt.Errorf("unknown service type %q", serviceType)
+	}
+
+	return scheme.Scheme.Convert(svc, u, nil)
+}
+
+// configureBootstrap applies the bootstrap settings of the spec to the Envoy bootstrap configuration
+// of the kourier gateway, and points it at the kourier control plane in the namespace of the
+// KnativeServing instance if the gateway runs in another namespace. The configuration is left as it
+// is if there is nothing to change, and only the clusters, which a setting is requested for, have
+// to be found in it.
+func configureBootstrap(instance *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != kourierBootstrapName || !hasProviderLabel(u) {
+			return nil
+		}
+		config := instance.Spec.Ingress.Kourier.Bootstrap
+		gatewayNamespace := instance.Spec.Ingress.Kourier.GatewayNamespace
+		relocated := gatewayNamespace != "" && gatewayNamespace != instance.GetNamespace()
+		if config == nil && !relocated {
+			return nil
+		}
+		if config == nil {
+			config = &v1alpha1.KourierBootstrapConfiguration{}
+		}
+
+		data, found, err := unstructured.NestedString(u.Object, "data", kourierBootstrapKey)
+		if err != nil || !found {
+			return err
+		}
+		bootstrap := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(data), &bootstrap); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", kourierBootstrapKey, err)
+		}
+
+		var controlAddress string
+		if relocated {
+			controlAddress = kourierControlServiceName + "." + instance.GetNamespace()
+		}
+		if err := updateBootstrapCluster(bootstrap, "xds_cluster", config.ControlConnectTimeout, controlAddress); err != nil {
+			return err
+		}
+		if err := updateBootstrapCluster(bootstrap, "service_stats", config.StatsConnectTimeout, ""); err != nil {
+			return err
+		}
+		if config.StatsPort != 0 {
+			listener := findNamed(bootstrap, "listeners", "stats_listener")
+			if listener == nil {
+				return fmt.Errorf("stats_listener not found in %s", kourierBootstrapKey)
 			}
-			svc := &v1.Service{}
-			if err := scheme.Scheme.Convert(u, svc, nil); err != nil {
+			if err := unstructured.SetNestedField(listener, int64(config.StatsPort), "address", "socket_address", "port_value"); err != nil {
 				return err
 			}
+		}
 
-			serviceType := instance.Spec.Ingress.Kourier.ServiceType
-			switch serviceType {
-			case v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
-				svc.Spec.Type = serviceType
-			default:
-				return fmt.Errorf("unknown service type %q", serviceType)
-			}
+		out, err := yaml.Marshal(bootstrap)
+		if err != nil {
+			return err
+		}
+		return unstructured.SetNestedField(u.Object, string(out), "data", kourierBootstrapKey)
+	}
+}
 
-			if err := scheme.Scheme.Convert(svc, u, nil); err != nil {
-				return err
+// updateBootstrapCluster sets the connect timeout and the address of the endpoints of a static
+// cluster of the bootstrap configuration. Empty values are left as they are, and the cluster does
+// not have to exist if both are empty.
+func updateBootstrapCluster(bootstrap map[string]interface{}, name, timeout, address string) error {
+	if timeout == "" && address == "" {
+		return nil
+	}
+	cluster := findNamed(bootstrap, "clusters", name)
+	if cluster == nil {
+		return fmt.Errorf("cluster %s not found in %s", name, kourierBootstrapKey)
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid connect timeout %q of cluster %s", timeout, name)
+		}
+		// Envoy expects the durations in seconds.
+		cluster["connect_timeout"] = strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	}
+	if address != "" {
+		setSocketAddresses(cluster["load_assignment"], address)
+	}
+	return nil
+}
+
+// setSocketAddresses sets the address of all the socket addresses found in v.
+func setSocketAddresses(v interface{}, address string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if socket, ok := value.(map[string]interface{}); ok && key == "socket_address" {
+				socket["address"] = address
+				continue
 			}
+			setSocketAddresses(value, address)
+		}
+	case []interface{}:
+		for _, value := range v {
+			setSocketAddresses(value, address)
+		}
+	}
+}
+
+// findNamed returns the element with the given name from a list of the static resources of the
+// bootstrap configuration.
+func findNamed(bootstrap map[string]interface{}, key, name string) map[string]interface{} {
+	field, _, _ := unstructured.NestedFieldNoCopy(bootstrap, "static_resources", key)
+	items, _ := field.([]interface{})
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok && m["name"] == name {
+			return m
 		}
-		return nil
 	}
+	return nil
 }
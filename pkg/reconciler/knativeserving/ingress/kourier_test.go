@@ -28,6 +28,7 @@ import (
 	"k8s.io/client-go/kubernetes/scheme"
 	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
 	util "knative.dev/operator/pkg/reconciler/common/testing"
+	"sigs.k8s.io/yaml"
 )
 
 const servingNamespace = "knative-serving"
@@ -72,6 +73,18 @@ func TestTransformKourierManifest(t *testing.T) {
 		instance:       servingInstance(servingNamespace, "ClusterIP"),
 		expNamespace:   "kourier-system", // kourier default namespace
 		expServiceType: "LoadBalancer",   // kourier GW default service type
+	}, {
+		name: "Replaces Kourier Gateway Namespace with the gateway namespace",
+		instance: &servingv1alpha1.KnativeServing{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: servingNamespace},
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true, GatewayNamespace: "kourier-gateways"},
+				},
+			},
+		},
+		expNamespace:   "kourier-gateways",
+		expServiceType: "LoadBalancer",
 	}}
 
 	for _, tt := range tests {
@@ -89,7 +102,7 @@ func TestTransformKourierManifest(t *testing.T) {
 				}
 			}
 
-			manifest, err = manifest.Transform(replaceGWNamespace())
+			manifest, err = manifest.Transform(replaceGWNamespace(tt.instance))
 			if err != nil {
 				t.Fatalf("Failed to transform manifest: %v", err)
 			}
@@ -107,6 +120,280 @@ func TestTransformKourierManifest(t *testing.T) {
 	}
 }
 
+func TestRelocateGateway(t *testing.T) {
+	tests := []struct {
+		name      string
+		namespace string
+		dropLabel bool
+		expected  map[string]string
+	}{{
+		name: "Keep the gateway in the namespace of the manifest",
+		expected: map[string]string{
+			"ConfigMap/kourier-bootstrap":       "kourier-system",
+			"Deployment/3scale-kourier-gateway": "kourier-system",
+			"Deployment/3scale-kourier-control": servingNamespace,
+			"Service/kourier":                   "kourier-system",
+			"Service/kourier-control":           servingNamespace,
+		},
+	}, {
+		name:      "Move the gateway to the gateway namespace",
+		namespace: "kourier-gateways",
+		expected: map[string]string{
+			"ConfigMap/kourier-bootstrap":       "kourier-gateways",
+			"Deployment/3scale-kourier-gateway": "kourier-gateways",
+			"Deployment/3scale-kourier-control": servingNamespace,
+			"Service/kourier":                   "kourier-gateways",
+			"Service/kourier-control":           servingNamespace,
+		},
+	}, {
+		name:      "Do not transform without the ingress provider label",
+		namespace: "kourier-gateways",
+		dropLabel: true,
+		expected: map[string]string{
+			"ConfigMap/kourier-bootstrap":       "kourier-system",
+			"Deployment/3scale-kourier-gateway": "kourier-system",
+			"Service/kourier":                   "kourier-system",
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.20/kourier.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+			manifest, err = manifest.Transform(mf.InjectOwner(servingInstance(servingNamespace, "")), relocateGateway(tt.namespace))
+			if err != nil {
+				t.Fatalf("Failed to transform manifest: %v", err)
+			}
+
+			for _, u := range manifest.Resources() {
+				ns, ok := tt.expected[u.GetKind()+"/"+u.GetName()]
+				if !ok {
+					continue
+				}
+				util.AssertEqual(t, u.GetNamespace(), ns)
+				// Only the relocated resources lose their OwnerReference.
+				util.AssertEqual(t, len(u.GetOwnerReferences()) == 0, ns == "kourier-gateways")
+			}
+		})
+	}
+}
+
+func TestInstalledKourierTransformers(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		status   servingv1alpha1.KnativeServingStatus
+		expected string
+	}{{
+		name:     "Move the gateway to the recorded gateway namespace",
+		spec:     "kourier-system",
+		status:   servingv1alpha1.KnativeServingStatus{Ingresses: []string{"kourier"}, KourierGatewayNamespace: "kourier-gateways"},
+		expected: "kourier-gateways",
+	}, {
+		name:     "Move the gateway back to the namespace of the instance",
+		spec:     "kourier-gateways",
+		status:   servingv1alpha1.KnativeServingStatus{Ingresses: []string{"kourier"}},
+		expected: servingNamespace,
+	}, {
+		name:     "Use the gateway namespace of the spec without a recorded one",
+		spec:     "kourier-gateways",
+		expected: "kourier-gateways",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-instance", Namespace: servingNamespace},
+				Spec: servingv1alpha1.KnativeServingSpec{
+					Ingress: &servingv1alpha1.IngressConfigs{
+						Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true, GatewayNamespace: tt.spec},
+					},
+				},
+				Status: tt.status,
+			}
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.20/kourier.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			manifest, err = manifest.Transform(installedKourierTransformers(instance)...)
+			if err != nil {
+				t.Fatalf("Failed to transform manifest: %v", err)
+			}
+
+			gateway := manifest.Filter(mf.ByKind("Deployment"), mf.ByName("3scale-kourier-gateway")).Resources()
+			util.AssertEqual(t, len(gateway), 1)
+			util.AssertEqual(t, gateway[0].GetNamespace(), tt.expected)
+		})
+	}
+}
+
+func TestConfigureBootstrap(t *testing.T) {
+	tests := []struct {
+		name              string
+		namespace         string
+		gatewayNamespace  string
+		bootstrap         *servingv1alpha1.KourierBootstrapConfiguration
+		dropLabel         bool
+		expStatsPort      float64
+		expControlTimeout string
+		expStatsTimeout   string
+		expControlAddress string
+		expErr            bool
+	}{{
+		name:              "Keep the default settings",
+		namespace:         servingNamespace,
+		expStatsPort:      9000,
+		expControlTimeout: "1s",
+		expStatsTimeout:   "0.250s",
+		expControlAddress: "kourier-control.knative-serving",
+	}, {
+		name:      "Override the settings",
+		namespace: "serving",
+		bootstrap: &servingv1alpha1.KourierBootstrapConfiguration{
+			StatsPort:             9090,
+			ControlConnectTimeout: "2500ms",
+			StatsConnectTimeout:   "1s",
+		},
+		expStatsPort:      9090,
+		expControlTimeout: "2.5s",
+		expStatsTimeout:   "1s",
+		expControlAddress: "kourier-control.knative-serving",
+	}, {
+		name:              "Point the relocated gateway at the control plane",
+		namespace:         "serving",
+		gatewayNamespace:  "kourier-gateways",
+		expStatsPort:      9000,
+		expControlTimeout: "1s",
+		expStatsTimeout:   "0.250s",
+		expControlAddress: "kourier-control.serving",
+	}, {
+		name:      "Do not transform without the ingress provider label",
+		namespace: "serving",
+		bootstrap: &servingv1alpha1.KourierBootstrapConfiguration{
+			StatsPort: 9090,
+		},
+		dropLabel:         true,
+		expStatsPort:      9000,
+		expControlTimeout: "1s",
+		expStatsTimeout:   "0.250s",
+		expControlAddress: "kourier-control.knative-serving",
+	}, {
+		name:      "Invalid timeout",
+		namespace: servingNamespace,
+		bootstrap: &servingv1alpha1.KourierBootstrapConfiguration{
+			ControlConnectTimeout: "1 second",
+		},
+		expErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, err := mf.NewManifest("testdata/kodata/ingress/0.20/kourier.yaml")
+			if err != nil {
+				t.Fatalf("Failed to read manifest: %v", err)
+			}
+			if tt.dropLabel {
+				manifest, err = manifest.Transform(removeLabels())
+				if err != nil {
+					t.Fatalf("Failed to transform manifest: %v", err)
+				}
+			}
+			instance := servingInstance(tt.namespace, "")
+			instance.Spec.Ingress.Kourier.GatewayNamespace = tt.gatewayNamespace
+			instance.Spec.Ingress.Kourier.Bootstrap = tt.bootstrap
+			manifest, err = manifest.Transform(configureBootstrap(instance))
+			util.AssertEqual(t, err != nil, tt.expErr)
+			if tt.expErr {
+				return
+			}
+
+			resources := manifest.Filter(mf.ByKind("ConfigMap"), mf.ByName(kourierBootstrapName)).Resources()
+			util.AssertEqual(t, len(resources), 1)
+			data, _, _ := unstructured.NestedString(resources[0].Object, "data", kourierBootstrapKey)
+			bootstrap := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(data), &bootstrap); err != nil {
+				t.Fatalf("Failed to parse the bootstrap configuration: %v", err)
+			}
+			util.AssertDeepEqual(t, findNamed(bootstrap, "listeners", "stats_listener")["address"],
+				map[string]interface{}{"socket_address": map[string]interface{}{"address": "0.0.0.0", "port_value": tt.expStatsPort}})
+			xds := findNamed(bootstrap, "clusters", "xds_cluster")
+			util.AssertEqual(t, xds["connect_timeout"], tt.expControlTimeout)
+			address, _, _ := unstructured.NestedString(xds, "load_assignment", "endpoints", "lb_endpoints", "endpoint", "address", "socket_address", "address")
+			util.AssertEqual(t, address, tt.expControlAddress)
+			util.AssertEqual(t, findNamed(bootstrap, "clusters", "service_stats")["connect_timeout"], tt.expStatsTimeout)
+		})
+	}
+}
+
+func TestConfigureBootstrapLayout(t *testing.T) {
+	// A bootstrap configuration of a custom release without the service_stats cluster.
+	const data = `static_resources:
+  clusters:
+  - name: xds_cluster
+    connect_timeout: 1s
+`
+	tests := []struct {
+		name             string
+		gatewayNamespace string
+		bootstrap        *servingv1alpha1.KourierBootstrapConfiguration
+		expData          string
+		expErr           bool
+	}{{
+		name:    "Keep the configuration without settings",
+		expData: data,
+	}, {
+		name:             "Keep the configuration of a gateway in the namespace of the instance",
+		gatewayNamespace: servingNamespace,
+		expData:          data,
+	}, {
+		name: "Set the timeout of an existing cluster",
+		bootstrap: &servingv1alpha1.KourierBootstrapConfiguration{
+			ControlConnectTimeout: "2s",
+		},
+		expData: `static_resources:
+  clusters:
+  - connect_timeout: 2s
+    name: xds_cluster
+`,
+	}, {
+		name: "Set the timeout of a missing cluster",
+		bootstrap: &servingv1alpha1.KourierBootstrapConfiguration{
+			StatsConnectTimeout: "2s",
+		},
+		expErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &unstructured.Unstructured{}
+			u.SetAPIVersion("v1")
+			u.SetKind("ConfigMap")
+			u.SetName(kourierBootstrapName)
+			u.SetLabels(map[string]string{providerLabel: "kourier"})
+			unstructured.SetNestedField(u.Object, data, "data", kourierBootstrapKey)
+
+			instance := servingInstance(servingNamespace, "")
+			instance.Spec.Ingress.Kourier.GatewayNamespace = tt.gatewayNamespace
+			instance.Spec.Ingress.Kourier.Bootstrap = tt.bootstrap
+			err := configureBootstrap(instance)(u)
+			util.AssertEqual(t, err != nil, tt.expErr)
+			if tt.expErr {
+				return
+			}
+			got, _, _ := unstructured.NestedString(u.Object, "data", kourierBootstrapKey)
+			util.AssertEqual(t, got, tt.expData)
+		})
+	}
+}
+
 func verifyControllerNamespace(t *testing.T, u *unstructured.Unstructured, expNamespace string) {
 	if u.GetKind() == "Deployment" && kourierControllerDeploymentNames.Has(u.GetName()) {
 		deployment := &appsv1.Deployment{}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"golang.org/x/mod/semver"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+)
+
+// manifestFetcher returns the manifest for the path.
+type manifestFetcher func(context.Context, v1alpha1.KComponent, string) (mf.Manifest, error)
+
+// appendIngressReleases replaces the resources of the ingresses with pinned releases in the manifest
+// of the ingresses matching the version of Knative Serving with the resources of the pinned releases,
+// which are fetched with fetch.
+func appendIngressReleases(ctx context.Context, ks *v1alpha1.KnativeServing, releases map[string]v1alpha1.IngressRelease,
+	fetch manifestFetcher, version string, m mf.Manifest) (mf.Manifest, error) {
+	if len(releases) == 0 {
+		return m, nil
+	}
+	names := make([]string, 0, len(releases))
+	for name := range releases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pinned := make([]mf.Predicate, 0, len(names))
+	for _, name := range names {
+		pinned = append(pinned, providerFilter(name))
+	}
+	result := m.Filter(mf.Not(mf.Any(pinned...)))
+	for _, name := range names {
+		rm, err := fetchIngressRelease(ctx, ks, name, releases[name], fetch, version)
+		if err != nil {
+			return mf.Manifest{}, err
+		}
+		result = result.Append(rm)
+	}
+	return result, nil
+}
+
+// fetchIngressRelease returns the manifest of the pinned release of an ingress. The manifests of
+// the release are used as they are, while only the resources of the ingress are kept from the
+// catalog.
+func fetchIngressRelease(ctx context.Context, ks *v1alpha1.KnativeServing, name string, release v1alpha1.IngressRelease,
+	fetch manifestFetcher, servingVersion string) (mf.Manifest, error) {
+	if len(release.Manifests) != 0 {
+		path := common.ManifestPath(ks, release.Manifests, common.IngressVersion(release, servingVersion))
+		return fetch(ctx, ks, path)
+	}
+
+	var path string
+	var err error
+	if strings.Count(release.Version, ".") > 1 {
+		// A patch version has to match exactly.
+		path, err = common.CatalogManifests(common.IngressCatalog, strings.TrimPrefix(release.Version, "v"), "")
+	} else {
+		path, err = common.AlternativeManifests(common.IngressCatalog, release.Version, "")
+	}
+	if err != nil {
+		return mf.Manifest{}, fmt.Errorf("the version %s of the ingress %s is not available: %w", release.Version, name, err)
+	}
+	m, err := fetch(ctx, ks, path)
+	if err != nil {
+		return mf.Manifest{}, err
+	}
+	return m.Filter(providerFilter(name)), nil
+}
+
+// installedIngressReleases returns the releases pinned for the ingresses of the installed release.
+// The releases pinned in the spec are used if the status does not record the enabled ingresses.
+func installedIngressReleases(ks *v1alpha1.KnativeServing) map[string]v1alpha1.IngressRelease {
+	if ks.Status.Ingresses == nil {
+		return ks.Spec.IngressReleases()
+	}
+	return ks.Status.IngressReleases
+}
+
+// providerFilter keeps only the resources labelled with the provider of the ingress.
+func providerFilter(name string) mf.Predicate {
+	return mf.All(hasProviderLabel, ingressFilters[name])
+}
+
+// validateIngressReleases checks that the versions of the pinned releases of the ingresses are
+// compatible with the version of Knative Serving, i.e. they have the same major version, and
+// their minor versions differ by one at most. The versions of Knative Serving, which are not
+// semantic versions, e.g. latest, are not validated.
+func validateIngressReleases(ks *v1alpha1.KnativeServing, servingVersion string) error {
+	servingMajor, servingMinor, ok := majorMinor(servingVersion)
+	for name, release := range ks.Spec.IngressReleases() {
+		if release.Version == "" {
+			continue
+		}
+		major, minor, valid := majorMinor(release.Version)
+		if !valid {
+			return fmt.Errorf("invalid version %q of the ingress %s", release.Version, name)
+		}
+		if !ok {
+			continue
+		}
+		if major != servingMajor || minor < servingMinor-1 || minor > servingMinor+1 {
+			return fmt.Errorf("the version %s of the ingress %s is not compatible with the version %s of Knative Serving",
+				release.Version, name, servingVersion)
+		}
+	}
+	return nil
+}
+
+// majorMinor returns the major and minor numbers of a version, e.g. 0.24.1 or 0.24.
+func majorMinor(version string) (int, int, bool) {
+	v := common.SanitizeSemver(strings.TrimPrefix(version, "v"))
+	if !semver.IsValid(v) {
+		return 0, 0, false
+	}
+	parts := strings.Split(strings.TrimPrefix(semver.MajorMinor(v), "v"), ".")
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,297 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ingress
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestGetIngressWithReleases(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	tests := []struct {
+		name      string
+		instance  *servingv1alpha1.KnativeServing
+		expected  map[string]sets.String
+		expectErr bool
+	}{{
+		name: "No pinned release",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio:   servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+					Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.22.1"),
+			"kourier": sets.NewString("v0.22.0"),
+		},
+	}, {
+		name: "Pin the version of kourier",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio: servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+					Kourier: servingv1alpha1.KourierIngressConfiguration{
+						Enabled:        true,
+						IngressRelease: servingv1alpha1.IngressRelease{Version: "0.21"},
+					},
+				},
+			},
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.22.1"),
+			"kourier": sets.NewString("v0.21.0"),
+		},
+	}, {
+		name: "Pin the manifests of istio",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio: servingv1alpha1.IstioIngressConfiguration{
+						Enabled: true,
+						IngressRelease: servingv1alpha1.IngressRelease{
+							Manifests: []servingv1alpha1.Manifest{{Url: "testdata/kodata/ingress/${VERSION}/net-istio.yaml"}},
+							Version:   "0.21",
+						},
+					},
+					Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+				},
+			},
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.21.0"),
+			"kourier": sets.NewString("v0.22.0"),
+		},
+	}, {
+		name: "Unavailable patch version",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				Ingress: &servingv1alpha1.IngressConfigs{
+					Istio: servingv1alpha1.IstioIngressConfiguration{Enabled: true},
+					Kourier: servingv1alpha1.KourierIngressConfiguration{
+						Enabled:        true,
+						IngressRelease: servingv1alpha1.IngressRelease{Version: "0.21.3"},
+					},
+				},
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _ := mf.ManifestFrom(mf.Slice{})
+			err := getIngress(context.TODO(), tt.instance, "0.22.0", tt.instance.Spec.IngressReleases(), common.FetchManifest, &manifest)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if tt.expectErr {
+				return
+			}
+			util.AssertDeepEqual(t, providerReleases(manifest), tt.expected)
+		})
+	}
+}
+
+func TestAppendInstalledIngressesWithReleases(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	tests := []struct {
+		name     string
+		status   servingv1alpha1.KnativeServingStatus
+		expected map[string]sets.String
+	}{{
+		name: "Installed with the pinned release",
+		status: servingv1alpha1.KnativeServingStatus{
+			Version:         "0.22.0",
+			Ingresses:       []string{"istio", "kourier"},
+			IngressReleases: map[string]servingv1alpha1.IngressRelease{"kourier": {Version: "0.21"}},
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.22.1"),
+			"kourier": sets.NewString("v0.21.0"),
+		},
+	}, {
+		name: "Installed without pinned releases",
+		status: servingv1alpha1.KnativeServingStatus{
+			Version:   "0.22.0",
+			Ingresses: []string{"istio", "kourier"},
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.22.1"),
+			"kourier": sets.NewString("v0.22.0"),
+		},
+	}, {
+		name: "Pinned releases not recorded",
+		status: servingv1alpha1.KnativeServingStatus{
+			Version: "0.22.0",
+		},
+		expected: map[string]sets.String{
+			"istio":   sets.NewString("v0.21.0"),
+			"kourier": sets.NewString("v0.22.0"),
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The spec pins another release than the installed one.
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.0"},
+					Ingress: &servingv1alpha1.IngressConfigs{
+						Istio: servingv1alpha1.IstioIngressConfiguration{
+							Enabled:        true,
+							IngressRelease: servingv1alpha1.IngressRelease{Version: "0.21"},
+						},
+						Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+					},
+				},
+				Status: tt.status,
+			}
+			manifest, _ := mf.ManifestFrom(mf.Slice{})
+			util.AssertEqual(t, AppendInstalledIngresses(context.TODO(), &manifest, instance), nil)
+			util.AssertDeepEqual(t, providerReleases(manifest), tt.expected)
+		})
+	}
+}
+
+func TestAppendTargetIngressesPinnedManifestChanges(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	path := filepath.Join(t.TempDir(), "kourier.yaml")
+	instance := &servingv1alpha1.KnativeServing{
+		ObjectMeta: metav1.ObjectMeta{Name: "knative-serving", Namespace: "pinned-manifest-changes"},
+		Spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.0"},
+			Ingress: &servingv1alpha1.IngressConfigs{
+				Kourier: servingv1alpha1.KourierIngressConfiguration{
+					Enabled: true,
+					IngressRelease: servingv1alpha1.IngressRelease{
+						Manifests: []servingv1alpha1.Manifest{{Url: path}},
+					},
+				},
+			},
+		},
+	}
+	defer common.EvictCache(instance)
+
+	// The changes to the content of the pinned manifests are picked up without waiting for the cache.
+	for _, version := range []string{"0.21", "0.22"} {
+		data, err := ioutil.ReadFile("testdata/kodata/ingress/" + version + "/kourier.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		manifest, _ := mf.ManifestFrom(mf.Slice{})
+		util.AssertEqual(t, AppendTargetIngresses(context.TODO(), &manifest, instance), nil)
+		util.AssertDeepEqual(t, providerReleases(manifest)["kourier"], sets.NewString("v"+version+".0"))
+	}
+}
+
+// providerReleases returns the releases of the resources of istio and kourier in the manifest.
+func providerReleases(manifest mf.Manifest) map[string]sets.String {
+	releases := map[string]sets.String{}
+	for _, u := range manifest.Resources() {
+		provider := u.GetLabels()[providerLabel]
+		if provider != "istio" && provider != "kourier" {
+			continue
+		}
+		if releases[provider] == nil {
+			releases[provider] = sets.NewString()
+		}
+		releases[provider].Insert(u.GetLabels()["serving.knative.dev/release"])
+	}
+	return releases
+}
+
+func TestValidateIngressReleases(t *testing.T) {
+	tests := []struct {
+		name           string
+		servingVersion string
+		version        string
+		expectErr      bool
+	}{{
+		name:           "No pinned version",
+		servingVersion: "0.24.0",
+	}, {
+		name:           "Same version",
+		servingVersion: "0.24.0",
+		version:        "0.24.1",
+	}, {
+		name:           "Previous minor version",
+		servingVersion: "0.24.0",
+		version:        "0.23",
+	}, {
+		name:           "Next minor version",
+		servingVersion: "0.24.0",
+		version:        "v0.25.0",
+	}, {
+		name:           "Incompatible minor version",
+		servingVersion: "0.24.0",
+		version:        "0.22.0",
+		expectErr:      true,
+	}, {
+		name:           "Incompatible major version",
+		servingVersion: "0.24.0",
+		version:        "1.24.0",
+		expectErr:      true,
+	}, {
+		name:           "Invalid version",
+		servingVersion: "0.24.0",
+		version:        "latest",
+		expectErr:      true,
+	}, {
+		name:           "Serving version without semantic version",
+		servingVersion: "latest",
+		version:        "0.20.0",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := &servingv1alpha1.KnativeServing{
+				Spec: servingv1alpha1.KnativeServingSpec{
+					Ingress: &servingv1alpha1.IngressConfigs{
+						Istio: servingv1alpha1.IstioIngressConfiguration{
+							Enabled:        true,
+							IngressRelease: servingv1alpha1.IngressRelease{Version: tt.version},
+						},
+						Kourier: servingv1alpha1.KourierIngressConfiguration{Enabled: true},
+					},
+				},
+			}
+			err := validateIngressReleases(instance, tt.servingVersion)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+		})
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"context"
 	"fmt"
 
+	"knative.dev/operator/pkg/reconciler/knativeserving/domain"
 	"knative.dev/operator/pkg/reconciler/knativeserving/ingress"
+	"knative.dev/operator/pkg/reconciler/knativeserving/tls"
 
 	mf "github.com/manifestival/manifestival"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 
 	"knative.dev/operator/pkg/apis/operator/v1alpha1"
@@ -33,6 +36,7 @@ import (
 	ksc "knative.dev/operator/pkg/reconciler/knativeserving/common"
 	"knative.dev/pkg/logging"
 	pkgreconciler "knative.dev/pkg/reconciler"
+	"knative.dev/pkg/tracker"
 )
 
 // Reconciler implements controller.Reconciler for Knativeserving resources.
@@ -48,6 +52,10 @@ type Reconciler struct {
 	manifest mf.Manifest
 	// Platform-specific behavior to affect the transform
 	extension common.Extension
+	// tracker tracks the ConfigMaps and Secrets containing the manifests
+	tracker tracker.Interface
+	// discovery caches the API discovery to check the dependencies
+	discovery discovery.CachedDiscoveryInterface
 }
 
 // Check that our Reconciler implements controller.Reconciler
@@ -58,8 +66,8 @@ var _ knsreconciler.Finalizer = (*Reconciler)(nil)
 func (r *Reconciler) FinalizeKind(ctx context.Context, original *v1alpha1.KnativeServing) pkgreconciler.Event {
 	logger := logging.FromContext(ctx)
 
-	// Clean up the cache, if the Serving CR is deleted.
-	common.ClearCache()
+	// Evict the manifests of this CR from the cache, since it is deleted.
+	common.EvictCache(original)
 
 	// List all KnativeServings to determine if cluster-scoped resources should be deleted.
 	kss, err := r.operatorClientSet.OperatorV1alpha1().KnativeServings("").List(ctx, metav1.ListOptions{})
@@ -98,6 +106,10 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ks *v1alpha1.KnativeServ
 
 	logger.Infow("Reconciling KnativeServing", "status", ks.Status)
 
+	if err := common.TrackManifestSources(r.tracker, ks); err != nil {
+		return err
+	}
+
 	if err := common.IsVersionValidMigrationEligible(ks); err != nil {
 		ks.Status.MarkVersionMigrationNotEligible(err.Error())
 		return nil
@@ -108,12 +120,16 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ks *v1alpha1.KnativeServ
 		return err
 	}
 	stages := common.Stages{
+		common.CheckDependencies(r.discovery, dependencies),
 		common.AppendTarget,
 		ingress.AppendTargetIngresses,
+		tls.AppendTargetTLS,
+		domain.AppendTargetDomain,
 		common.AppendAdditionalManifests,
 		r.filterDisabledIngresses,
 		r.appendExtensionManifests,
 		r.transform,
+		domain.RemoveMagicDNS,
 		common.Install,
 		common.CheckDeployments,
 		common.DeleteObsoleteResources(ctx, ks, r.installed),
@@ -122,6 +138,11 @@ func (r *Reconciler) ReconcileKind(ctx context.Context, ks *v1alpha1.KnativeServ
 	return stages.Execute(ctx, &manifest, ks)
 }
 
+// dependencies returns the resources required by the enabled ingresses and the automatic TLS.
+func dependencies(instance v1alpha1.KComponent) []common.Dependency {
+	return append(ingress.Dependencies(instance), tls.Dependencies(instance)...)
+}
+
 // filterDisabledIngresses removes the disabled ingresses from the manifests
 func (r *Reconciler) filterDisabledIngresses(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
 	ks := instance.(*v1alpha1.KnativeServing)
@@ -129,6 +150,14 @@ func (r *Reconciler) filterDisabledIngresses(ctx context.Context, manifest *mf.M
 	return nil
 }
 
+// filterInstalledIngresses removes the ingresses, which were not enabled for the installed release,
+// from the manifests
+func (r *Reconciler) filterInstalledIngresses(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	*manifest = manifest.Filter(ingress.InstalledFilters(ks))
+	return nil
+}
+
 // transform mutates the passed manifest to one with common, component
 // and platform transformations applied
 func (r *Reconciler) transform(ctx context.Context, manifest *mf.Manifest, comp v1alpha1.KComponent) error {
@@ -137,18 +166,34 @@ func (r *Reconciler) transform(ctx context.Context, manifest *mf.Manifest, comp
 	extra := []mf.Transformer{
 		ksc.CustomCertsTransform(instance, logger),
 		ksc.AggregationRuleTransform(manifest.Client),
+		domain.ExistingJobTransform(manifest.Client),
 	}
 	extra = append(extra, r.extension.Transformers(instance)...)
 	extra = append(extra, ksc.IngressServiceTransform(instance))
 	extra = append(extra, ingress.Transformers(ctx, instance)...)
+	extra = append(extra, tls.Transformers(instance)...)
+	extra = append(extra, domain.Transformers(instance)...)
 	return common.Transform(ctx, manifest, instance, extra...)
 }
 
+// transformInstalled moves the resources of the installed manifest to the namespaces recorded in the
+// status, which may differ from the ones of the spec
+func (r *Reconciler) transformInstalled(ctx context.Context, manifest *mf.Manifest, comp v1alpha1.KComponent) error {
+	instance := comp.(*v1alpha1.KnativeServing)
+	transformers := append(ingress.InstalledTransformers(instance), ksc.InstalledIngressServiceTransform(instance))
+	transformed, err := manifest.Transform(transformers...)
+	if err != nil {
+		return err
+	}
+	*manifest = transformed
+	return nil
+}
+
 func (r *Reconciler) installed(ctx context.Context, instance v1alpha1.KComponent) (*mf.Manifest, error) {
 	// Create new, empty manifest with valid client and logger
 	installed := r.manifest.Append()
-	stages := common.Stages{common.AppendInstalled, ingress.AppendInstalledIngresses, r.filterDisabledIngresses,
-		r.transform}
+	stages := common.Stages{common.AppendInstalled, ingress.AppendInstalledIngresses, tls.AppendInstalledTLS,
+		domain.AppendInstalledDomain, r.filterInstalledIngresses, r.transform, r.transformInstalled}
 	err := stages.Execute(ctx, &installed, instance)
 	return &installed, err
 }
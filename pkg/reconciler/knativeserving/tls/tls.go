@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+)
+
+const (
+	networkConfigMapName     = "config-network"
+	certManagerConfigMapName = "config-certmanager"
+	issuerRefKey             = "issuerRef"
+)
+
+// autoTLSKeys are the keys of the automatic TLS in the config-network ConfigMap. The older releases
+// read autoTLS, and the newer ones auto-tls.
+var autoTLSKeys = []string{"autoTLS", "auto-tls"}
+
+var certManagerDependencies = []common.Dependency{
+	{Name: "cert-manager", Groups: []string{"cert-manager.io"}, Kind: "Certificate"},
+	{Name: "cert-manager", Groups: []string{"cert-manager.io"}, Kind: "ClusterIssuer"},
+}
+
+// Dependencies returns the resources required by the automatic TLS, if enabled.
+func Dependencies(instance v1alpha1.KComponent) []common.Dependency {
+	ks, ok := instance.(*v1alpha1.KnativeServing)
+	if !ok || !ks.Spec.TLSEnabled() {
+		return nil
+	}
+	return certManagerDependencies
+}
+
+// AppendTargetTLS appends the manifests of net-certmanager to be installed, if the automatic TLS
+// is enabled.
+func AppendTargetTLS(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	if !ks.Spec.TLSEnabled() {
+		return nil
+	}
+	return common.AppendAlternativeManifests(ctx, manifest, instance, common.CertManagerCatalog, common.TargetVersion(instance))
+}
+
+// AppendInstalledTLS appends the installed manifests of net-certmanager, if the automatic TLS was
+// enabled for the installed release.
+func AppendInstalledTLS(ctx context.Context, manifest *mf.Manifest, instance v1alpha1.KComponent) error {
+	ks := instance.(*v1alpha1.KnativeServing)
+	if !ks.Status.TLS {
+		return nil
+	}
+	version := instance.GetStatus().GetVersion()
+	if version == "" {
+		version = common.TargetVersion(instance)
+	}
+	return common.AppendAlternativeManifests(ctx, manifest, instance, common.CertManagerCatalog, version)
+}
+
+// Transformers returns the transformers of the automatic TLS, if enabled.
+func Transformers(ks *v1alpha1.KnativeServing) []mf.Transformer {
+	if !ks.Spec.TLSEnabled() {
+		return nil
+	}
+	return []mf.Transformer{autoTLSTransform(ks), issuerRefTransform(ks)}
+}
+
+// autoTLSTransform enables the automatic TLS in the config-network ConfigMap, unless it is set
+// in the spec.
+func autoTLSTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != networkConfigMapName {
+			return nil
+		}
+		for _, key := range autoTLSKeys {
+			if hasConfig(ks, networkConfigMapName, key) {
+				return nil
+			}
+		}
+		for _, key := range autoTLSKeys {
+			if err := unstructured.SetNestedField(u.Object, "Enabled", "data", key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// issuerRefTransform sets the issuerRef of the config-certmanager ConfigMap to the ClusterIssuer
+// of the spec, unless it is set in the spec.config.
+func issuerRefTransform(ks *v1alpha1.KnativeServing) mf.Transformer {
+	return func(u *unstructured.Unstructured) error {
+		if u.GetKind() != "ConfigMap" || u.GetName() != certManagerConfigMapName {
+			return nil
+		}
+		issuer := ks.Spec.TLS.ClusterIssuer
+		if issuer == "" || hasConfig(ks, certManagerConfigMapName, issuerRefKey) {
+			return nil
+		}
+		ref := fmt.Sprintf("kind: ClusterIssuer\nname: %s\n", issuer)
+		return unstructured.SetNestedField(u.Object, ref, "data", issuerRefKey)
+	}
+}
+
+// hasConfig returns whether the key of the ConfigMap is set in the spec.config. The "config-" prefix
+// of the name of the ConfigMap is optional.
+func hasConfig(ks *v1alpha1.KnativeServing, name, key string) bool {
+	for _, n := range []string{name, strings.TrimPrefix(name, "config-")} {
+		if _, ok := ks.Spec.Config[n][key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Knative Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tls
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	mf "github.com/manifestival/manifestival"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	servingv1alpha1 "knative.dev/operator/pkg/apis/operator/v1alpha1"
+	"knative.dev/operator/pkg/reconciler/common"
+	util "knative.dev/operator/pkg/reconciler/common/testing"
+)
+
+func TestAppendTargetTLS(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	tests := []struct {
+		name           string
+		instance       *servingv1alpha1.KnativeServing
+		expectAppended bool
+		expectedIssuer string
+		expectErr      bool
+	}{{
+		name: "Patch version of net-certmanager from the minor release",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.1"},
+				TLS:        &servingv1alpha1.TLSConfiguration{Enabled: true},
+			},
+		},
+		expectAppended: true,
+	}, {
+		name: "Cluster issuer set on the net-certmanager release",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22"},
+				TLS:        &servingv1alpha1.TLSConfiguration{Enabled: true, ClusterIssuer: "letsencrypt"},
+			},
+		},
+		expectAppended: true,
+		expectedIssuer: "kind: ClusterIssuer\nname: letsencrypt\n",
+	}, {
+		name: "Disabled TLS",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.1"},
+				TLS:        &servingv1alpha1.TLSConfiguration{ClusterIssuer: "letsencrypt"},
+			},
+		},
+	}, {
+		name: "Unavailable net-certmanager",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{Version: "0.16.1"},
+				TLS:        &servingv1alpha1.TLSConfiguration{Enabled: true},
+			},
+		},
+		expectErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _ := mf.ManifestFrom(mf.Slice{})
+			err := AppendTargetTLS(context.TODO(), &manifest, tt.instance)
+			util.AssertEqual(t, err != nil, tt.expectErr)
+			if !tt.expectAppended {
+				util.AssertEqual(t, len(manifest.Resources()), 0)
+				return
+			}
+			manifest, err = manifest.Transform(Transformers(tt.instance)...)
+			util.AssertEqual(t, err, nil)
+			util.AssertEqual(t, len(manifest.Filter(mf.ByKind("Deployment"), mf.ByName("networking-certmanager")).Resources()), 1)
+			certManager := manifest.Filter(mf.ByName("config-certmanager")).Resources()[0]
+			issuer, _, _ := unstructured.NestedString(certManager.Object, "data", "issuerRef")
+			util.AssertEqual(t, issuer, tt.expectedIssuer)
+		})
+	}
+}
+
+func TestAppendInstalledTLS(t *testing.T) {
+	os.Setenv(common.KoEnvKey, "testdata/kodata")
+	defer os.Unsetenv(common.KoEnvKey)
+
+	// The automatic TLS recorded in the status is used, rather than the one of the spec.
+	instance := &servingv1alpha1.KnativeServing{
+		Spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.1"},
+		},
+		Status: servingv1alpha1.KnativeServingStatus{
+			Version: "0.22.0",
+			TLS:     true,
+		},
+	}
+	manifest, _ := mf.ManifestFrom(mf.Slice{})
+	util.AssertEqual(t, AppendInstalledTLS(context.TODO(), &manifest, instance), nil)
+	util.AssertEqual(t, util.DeepMatchWithPath(manifest, os.Getenv(common.KoEnvKey)+"/net-certmanager/0.22"), true)
+
+	instance = &servingv1alpha1.KnativeServing{
+		Spec: servingv1alpha1.KnativeServingSpec{
+			CommonSpec: servingv1alpha1.CommonSpec{Version: "0.22.1"},
+			TLS:        &servingv1alpha1.TLSConfiguration{Enabled: true},
+		},
+	}
+	manifest, _ = mf.ManifestFrom(mf.Slice{})
+	util.AssertEqual(t, AppendInstalledTLS(context.TODO(), &manifest, instance), nil)
+	util.AssertEqual(t, len(manifest.Resources()), 0)
+}
+
+func TestTransformers(t *testing.T) {
+	tests := []struct {
+		name            string
+		instance        *servingv1alpha1.KnativeServing
+		expectedNetwork map[string]string
+		expectedIssuer  string
+	}{{
+		name:            "Disabled TLS",
+		instance:        &servingv1alpha1.KnativeServing{},
+		expectedNetwork: map[string]string{},
+	}, {
+		name: "Enabled TLS",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				TLS: &servingv1alpha1.TLSConfiguration{Enabled: true, ClusterIssuer: "letsencrypt"},
+			},
+		},
+		expectedNetwork: map[string]string{
+			"autoTLS":  "Enabled",
+			"auto-tls": "Enabled",
+		},
+		expectedIssuer: "kind: ClusterIssuer\nname: letsencrypt\n",
+	}, {
+		name: "Keep the settings of the spec.config",
+		instance: &servingv1alpha1.KnativeServing{
+			Spec: servingv1alpha1.KnativeServingSpec{
+				CommonSpec: servingv1alpha1.CommonSpec{
+					Config: map[string]map[string]string{
+						"network":     {"autoTLS": "Disabled"},
+						"certmanager": {"issuerRef": "kind: Issuer\nname: custom\n"},
+					},
+				},
+				TLS: &servingv1alpha1.TLSConfiguration{Enabled: true, ClusterIssuer: "letsencrypt"},
+			},
+		},
+		expectedNetwork: map[string]string{},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, _ := mf.ManifestFrom(mf.Slice{makeConfigMap("config-network"), makeConfigMap("config-certmanager")})
+			manifest, err := manifest.Transform(Transformers(tt.instance)...)
+			util.AssertEqual(t, err, nil)
+
+			network := manifest.Filter(mf.ByName("config-network")).Resources()[0]
+			data, _, _ := unstructured.NestedStringMap(network.Object, "data")
+			util.AssertDeepEqual(t, data, tt.expectedNetwork)
+
+			certManager := manifest.Filter(mf.ByName("config-certmanager")).Resources()[0]
+			issuer, _, _ := unstructured.NestedString(certManager.Object, "data", "issuerRef")
+			util.AssertEqual(t, issuer, tt.expectedIssuer)
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	util.AssertEqual(t, len(Dependencies(&servingv1alpha1.KnativeServing{})), 0)
+	util.AssertDeepEqual(t, Dependencies(&servingv1alpha1.KnativeServing{
+		Spec: servingv1alpha1.KnativeServingSpec{
+			TLS: &servingv1alpha1.TLSConfiguration{Enabled: true},
+		},
+	}), certManagerDependencies)
+}
+
+func makeConfigMap(name string) unstructured.Unstructured {
+	u := unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetName(name)
+	u.Object["data"] = map[string]interface{}{}
+	return u
+}
@@ -19,6 +19,7 @@ limitations under the License.
 package e2e
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -51,7 +52,7 @@ func TestKnativeEventingPostDowngrade(t *testing.T) {
 		resources.SetKodataDir()
 		defer os.Unsetenv(common.KoEnvKey)
 
-		_, err := common.TargetManifest(&v1alpha1.KnativeEventing{})
+		_, err := common.TargetManifest(context.TODO(), &v1alpha1.KnativeEventing{})
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -65,7 +66,7 @@ func TestKnativeEventingPostDowngrade(t *testing.T) {
 		}
 
 		// Based on the previous release version, get the deployment resources.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeEventing manifest: %v", err)
 		}
@@ -96,7 +97,7 @@ func TestKnativeServingPostDowngrade(t *testing.T) {
 		resources.SetKodataDir()
 		defer os.Unsetenv(common.KoEnvKey)
 
-		_, err := common.TargetManifest(&v1alpha1.KnativeServing{})
+		_, err := common.TargetManifest(context.TODO(), &v1alpha1.KnativeServing{})
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -110,7 +111,7 @@ func TestKnativeServingPostDowngrade(t *testing.T) {
 		}
 
 		// Based on the previous release version, get the deployment resources.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeServing manifest: %v", err)
 		}
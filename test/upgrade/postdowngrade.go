@@ -17,6 +17,7 @@ limitations under the License.
 package upgrade
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -72,7 +73,7 @@ func eventingCRPostDowngrade(t *testing.T) {
 		resources.SetKodataDir()
 		defer os.Unsetenv(common.KoEnvKey)
 
-		_, err := common.TargetManifest(&v1alpha1.KnativeEventing{})
+		_, err := common.TargetManifest(context.TODO(), &v1alpha1.KnativeEventing{})
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -86,7 +87,7 @@ func eventingCRPostDowngrade(t *testing.T) {
 		}
 
 		// Based on the previous release version, get the deployment resources.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeEventing manifest: %v", err)
 		}
@@ -116,7 +117,7 @@ func servingCRPostDowngrade(t *testing.T) {
 		resources.SetKodataDir()
 		defer os.Unsetenv(common.KoEnvKey)
 
-		_, err := common.TargetManifest(&v1alpha1.KnativeServing{})
+		_, err := common.TargetManifest(context.TODO(), &v1alpha1.KnativeServing{})
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -130,7 +131,7 @@ func servingCRPostDowngrade(t *testing.T) {
 		}
 
 		// Based on the previous release version, get the deployment resources.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeServing manifest: %v", err)
 		}
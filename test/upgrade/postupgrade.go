@@ -17,6 +17,7 @@ limitations under the License.
 package upgrade
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
@@ -97,7 +98,7 @@ func servingCRPostUpgrade(t *testing.T) {
 				},
 			},
 		}
-		targetManifest, err := common.TargetManifest(ks)
+		targetManifest, err := common.TargetManifest(context.TODO(), ks)
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -116,7 +117,7 @@ func servingCRPostUpgrade(t *testing.T) {
 		}
 		// Compare the previous manifest with the target manifest, we verify that all the obsolete resources
 		// do not exist any more.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeServing manifest: %v", err)
 		}
@@ -153,7 +154,7 @@ func eventingCRPostUpgrade(t *testing.T) {
 				},
 			},
 		}
-		targetManifest, err := common.TargetManifest(ke)
+		targetManifest, err := common.TargetManifest(context.TODO(), ke)
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -171,7 +172,7 @@ func eventingCRPostUpgrade(t *testing.T) {
 		}
 		// Compare the previous manifest with the target manifest, we verify that all the obsolete resources
 		// do not exist any more.
-		preManifest, err := common.TargetManifest(instance)
+		preManifest, err := common.TargetManifest(context.TODO(), instance)
 		if err != nil {
 			t.Fatalf("Failed to get KnativeEventing manifest: %v", err)
 		}
@@ -84,7 +84,7 @@ func servingCRPreUpgrade(t *testing.T) {
 				},
 			},
 		}
-		manifest, err := common.TargetManifest(kservingInstalled)
+		manifest, err := common.TargetManifest(context.TODO(), kservingInstalled)
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}
@@ -126,7 +126,7 @@ func eventingCRPreUpgrade(t *testing.T) {
 				},
 			},
 		}
-		manifest, err := common.TargetManifest(keventingInstalled)
+		manifest, err := common.TargetManifest(context.TODO(), keventingInstalled)
 		if err != nil {
 			t.Fatalf("Failed to get the manifest for Knative: %v", err)
 		}